@@ -0,0 +1,136 @@
+package domainnameshop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// challengeKey identifies the values of an ACME challenge record.
+type challengeKey struct {
+	zone  string
+	host  string
+	value string
+}
+
+// ChallengeName returns the name of the ACME DNS-01 challenge record for domain,
+// which may be a wildcard: _acme-challenge.example.com for both example.com and *.example.com.
+func ChallengeName(domain string) string {
+	return "_acme-challenge." + strings.TrimPrefix(removeFQDNTrailingDot(domain), "*.")
+}
+
+// PresentChallenge creates the TXT record for an ACME DNS-01 challenge of domain, a fully
+// qualified name in zone, with value, the digest of the key authorization. The ID of the
+// record is remembered, so CleanupChallenge deletes exactly this record even when the same
+// name has several challenge values, like when a certificate covers both a domain and its wildcard.
+func (p *Provider) PresentChallenge(ctx context.Context, zone string, domain string, value string) (err error) {
+	ctx, span := p.startSpan(ctx, "PresentChallenge", Attribute{AttrZone, zone})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	host, err := apiHost(ChallengeName(domain), zone)
+	if err != nil {
+		return err
+	}
+	record := dsDNSRecord{
+		Host: host,
+		Type: "TXT",
+		Data: encodeTXT(value),
+		TTL:  int(p.defaultTTL().Seconds()),
+	}
+	created, err := p.createDNSRecord(ctx, token, secret, zone, record)
+	if err != nil {
+		return err
+	}
+
+	p.challengesMu.Lock()
+	defer p.challengesMu.Unlock()
+	if p.challenges == nil {
+		p.challenges = make(map[challengeKey][]int)
+	}
+	key := challengeKey{zoneKey(zone), created.Host, value}
+	p.challenges[key] = append(p.challenges[key], created.ID)
+
+	if p.ChallengeLedger != nil {
+		entry := LedgerEntry{Zone: key.zone, Name: key.host, Value: value, RecordID: created.ID, CreatedAt: time.Now().UTC()}
+		if err := p.ChallengeLedger.Add(entry); err != nil {
+			return fmt.Errorf("recording challenge record %d in the ledger: %w", created.ID, err)
+		}
+	}
+	return nil
+}
+
+// CleanupChallenge deletes the TXT record PresentChallenge created for domain and value.
+// If it wasn't created by this Provider, for instance after a restart, a single TXT record
+// with the challenge name and value is looked up and deleted. It's not an error if there is none.
+func (p *Provider) CleanupChallenge(ctx context.Context, zone string, domain string, value string) (err error) {
+	ctx, span := p.startSpan(ctx, "CleanupChallenge", Attribute{AttrZone, zone})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	host, err := apiHost(ChallengeName(domain), zone)
+	if err != nil {
+		return err
+	}
+	key := challengeKey{zoneKey(zone), host, value}
+
+	if id, ok := p.takeChallenge(key); ok {
+		record := dsDNSRecord{ID: id, Host: host, Type: "TXT", Data: encodeTXT(value)}
+		if err := p.deleteDNSRecordByID(ctx, token, secret, zone, record); err != nil {
+			// Keep the ID so cleanup can be tried again
+			p.challengesMu.Lock()
+			p.challenges[key] = append(p.challenges[key], id)
+			p.challengesMu.Unlock()
+			return err
+		}
+		return p.forgetChallenge(key.zone, id)
+	}
+
+	records, err := p.getAllDomainRecords(ctx, token, secret, zone)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if r.Type == "TXT" && strings.EqualFold(r.Host, host) && decodeTXT(r.Data) == value {
+			if err := p.deleteDNSRecordByID(ctx, token, secret, zone, r); err != nil {
+				return err
+			}
+			return p.forgetChallenge(key.zone, r.ID)
+		}
+	}
+	return nil
+}
+
+// takeChallenge removes and returns the most recent record ID remembered for key.
+func (p *Provider) takeChallenge(key challengeKey) (int, bool) {
+	p.challengesMu.Lock()
+	defer p.challengesMu.Unlock()
+	ids := p.challenges[key]
+	if len(ids) == 0 {
+		return 0, false
+	}
+	id := ids[len(ids)-1]
+	if len(ids) == 1 {
+		delete(p.challenges, key)
+	} else {
+		p.challenges[key] = ids[:len(ids)-1]
+	}
+	return id, true
+}
+
+// forgetChallenge removes a deleted challenge record from the ledger, if any.
+func (p *Provider) forgetChallenge(zone string, id int) error {
+	if p.ChallengeLedger == nil {
+		return nil
+	}
+	return p.ChallengeLedger.Remove(zone, id)
+}
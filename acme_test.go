@@ -0,0 +1,57 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_ChallengeName(t *testing.T) {
+	for domain, expected := range map[string]string{
+		"example.com":       "_acme-challenge.example.com",
+		"*.example.com.":    "_acme-challenge.example.com",
+		"www.example.com":   "_acme-challenge.www.example.com",
+		"*.www.example.com": "_acme-challenge.www.example.com",
+	} {
+		if name := ChallengeName(domain); name != expected {
+			t.Fatalf("ChallengeName(%q) != %q => %q", domain, expected, name)
+		}
+	}
+}
+
+func Test_CleanupChallengeDeletesTrackedID(t *testing.T) {
+	errStop := errors.New("stop before the API call")
+	var deleted []int
+	p := &Provider{
+		zones: map[string]Domain{"example.com": {ID: 1, Name: "example.com"}},
+		Hooks: []MutationHook{MutationHookFuncs{Before: func(ctx context.Context, m Mutation) error {
+			deleted = append(deleted, m.RecordID)
+			return errStop
+		}}},
+		challenges: map[challengeKey][]int{
+			{"example.com", "_acme-challenge", "apex"}:     {10},
+			{"example.com", "_acme-challenge", "wildcard"}: {11, 12},
+		},
+	}
+
+	err := p.CleanupChallenge(context.Background(), "example.com.", "*.example.com", "wildcard")
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected errStop => %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != 12 {
+		t.Fatalf("deleted != [12] => %v", deleted)
+	}
+
+	// A failed cleanup keeps the ID for another attempt
+	if ids := p.challenges[challengeKey{"example.com", "_acme-challenge", "wildcard"}]; len(ids) != 2 {
+		t.Fatalf("len(ids) != 2 => %v", ids)
+	}
+
+	id, ok := p.takeChallenge(challengeKey{"example.com", "_acme-challenge", "apex"})
+	if !ok || id != 10 {
+		t.Fatalf("takeChallenge != 10 => %d, %v", id, ok)
+	}
+	if _, ok := p.challenges[challengeKey{"example.com", "_acme-challenge", "apex"}]; ok {
+		t.Fatal("empty challenge key not removed")
+	}
+}
@@ -0,0 +1,287 @@
+package domainnameshop
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+type actorKey struct{}
+
+// WithActor returns a context recording actor, e.g. a user or service name,
+// as the one responsible for changes made with it.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "" if there is none.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Audit outcomes.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry is a line of the audit log.
+type AuditEntry struct {
+	Time      time.Time    `json:"time"`
+	Zone      string       `json:"zone"`
+	Operation Operation    `json:"operation"`
+	RecordID  int          `json:"record_id,omitempty"`
+	Actor     string       `json:"actor,omitempty"`
+	Before    *AuditRecord `json:"before,omitempty"`
+	After     *AuditRecord `json:"after,omitempty"`
+	Outcome   string       `json:"outcome"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// AuditRecord is a record in an AuditEntry, TTL is in seconds.
+type AuditRecord struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	TTL  int    `json:"ttl"`
+	Data string `json:"data"`
+}
+
+func auditRecord(rec libdns.Record) *AuditRecord {
+	if rec == nil {
+		return nil
+	}
+	rr := rec.RR()
+	return &AuditRecord{Name: rr.Name, Type: rr.Type, TTL: int(rr.TTL.Seconds()), Data: rr.Data}
+}
+
+// AuditLogger is a MutationHook writing an AuditEntry as a JSON line for every change
+// attempted through the Provider. If an entry can't be written, all later changes are
+// refused with the error, so no change goes unrecorded.
+type AuditLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewAuditLogger returns an AuditLogger writing to w, typically a RotatingFile.
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{w: w}
+}
+
+func (l *AuditLogger) BeforeMutation(ctx context.Context, m Mutation) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return fmt.Errorf("audit log unavailable: %w", l.err)
+	}
+	return nil
+}
+
+func (l *AuditLogger) AfterMutation(ctx context.Context, m Mutation, err error) {
+	entry := AuditEntry{
+		Time:      time.Now().UTC(),
+		Zone:      m.Zone,
+		Operation: m.Operation,
+		RecordID:  m.RecordID,
+		Actor:     ActorFromContext(ctx),
+		Before:    auditRecord(m.Before),
+		After:     auditRecord(m.After),
+		Outcome:   AuditSuccess,
+	}
+	if err != nil {
+		entry.Outcome = AuditFailure
+		entry.Error = err.Error()
+	}
+
+	line, jsonErr := json.Marshal(entry)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if jsonErr != nil {
+		l.err = jsonErr
+		return
+	}
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		l.err = err
+	}
+}
+
+// Err returns the error that made the logger refuse changes, if any.
+func (l *AuditLogger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// RotatingFile is an append-only file that is rotated when it grows beyond MaxBytes.
+// Rotated files are named Path.1 (newest) to Path.MaxBackups (oldest).
+type RotatingFile struct {
+	Path string
+	// MaxBytes is the size at which the file is rotated, 0 never rotates.
+	MaxBytes int64
+	// MaxBackups is the number of rotated files kept, 0 keeps none.
+	MaxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func (r *RotatingFile) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.MaxBytes > 0 && r.size > 0 && r.size+int64(len(b)) > r.MaxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.f.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file, a later Write opens it again.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+
+	if r.MaxBackups <= 0 {
+		if err := os.Remove(r.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for i := r.MaxBackups - 1; i > 0; i-- {
+			if err := os.Rename(fmt.Sprintf("%s.%d", r.Path, i), fmt.Sprintf("%s.%d", r.Path, i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(r.Path, r.Path+".1"); err != nil {
+			return err
+		}
+	}
+
+	return r.open()
+}
+
+// AuditQuery selects audit entries, zero fields match everything.
+type AuditQuery struct {
+	Zone string
+	// Name matches the record name before or after the change, relative to the zone or fully qualified.
+	Name  string
+	Since time.Time
+	Until time.Time
+}
+
+func (q AuditQuery) matches(e AuditEntry) bool {
+	if q.Zone != "" && !strings.EqualFold(removeFQDNTrailingDot(q.Zone), e.Zone) {
+		return false
+	}
+	if q.Name != "" {
+		name, err := relativeName(q.Name, e.Zone)
+		if err != nil {
+			return false
+		}
+		if (e.Before == nil || !strings.EqualFold(e.Before.Name, name)) && (e.After == nil || !strings.EqualFold(e.After.Name, name)) {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// ReadAuditLog returns the entries of an audit log matching q.
+func ReadAuditLog(r io.Reader, q AuditQuery) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("line %d: %v", line, err)
+		}
+		if q.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// ReadAuditFiles returns the entries matching q from the audit log at path and its
+// rotated files, oldest first.
+func ReadAuditFiles(path string, q AuditQuery) ([]AuditEntry, error) {
+	paths := []string{path}
+	for i := 1; ; i++ {
+		rotated := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(rotated); err != nil {
+			break
+		}
+		paths = append([]string{rotated}, paths...)
+	}
+
+	var entries []AuditEntry
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := ReadAuditLog(f, q)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", p, err)
+		}
+		entries = append(entries, matched...)
+	}
+	return entries, nil
+}
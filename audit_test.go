@@ -0,0 +1,105 @@
+package domainnameshop
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_AuditLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewAuditLogger(&buf)
+	ctx := WithActor(context.Background(), "ci-deploy")
+
+	before := libdns.TXT{Name: "www", TTL: time.Hour, Text: "old"}
+	after := libdns.TXT{Name: "www", TTL: time.Hour, Text: "new"}
+	logger.AfterMutation(ctx, Mutation{Zone: "example.com", Operation: OperationUpdate, RecordID: 3, Before: before, After: after}, nil)
+	logger.AfterMutation(ctx, Mutation{Zone: "example.com", Operation: OperationDelete, RecordID: 4, Before: libdns.TXT{Name: "gone", Text: "x"}}, errors.New("HTTP 404"))
+	logger.AfterMutation(context.Background(), Mutation{Zone: "example.org", Operation: OperationCreate, RecordID: 5, After: after}, nil)
+
+	entries, err := ReadAuditLog(bytes.NewReader(buf.Bytes()), AuditQuery{Zone: "example.com."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) != 2 => %d", len(entries))
+	}
+	if e := entries[0]; e.Actor != "ci-deploy" || e.RecordID != 3 || e.Before.Data != "old" || e.After.Data != "new" || e.After.TTL != 3600 || e.Outcome != AuditSuccess {
+		t.Fatalf("unexpected entry => %+v", e)
+	}
+	if e := entries[1]; e.Outcome != AuditFailure || e.Error != "HTTP 404" || e.After != nil {
+		t.Fatalf("unexpected entry => %+v", e)
+	}
+
+	entries, err = ReadAuditLog(bytes.NewReader(buf.Bytes()), AuditQuery{Name: "gone.example.com."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].RecordID != 4 {
+		t.Fatalf("expected entry for gone => %+v", entries)
+	}
+
+	entries, err = ReadAuditLog(bytes.NewReader(buf.Bytes()), AuditQuery{Since: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries in the future => %+v", entries)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func Test_AuditLoggerRefusesAfterWriteError(t *testing.T) {
+	logger := NewAuditLogger(failingWriter{})
+	m := Mutation{Zone: "example.com", Operation: OperationCreate}
+	if err := logger.BeforeMutation(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	logger.AfterMutation(context.Background(), m, nil)
+	if err := logger.BeforeMutation(context.Background(), m); err == nil {
+		t.Fatal("expected changes to be refused after a write error")
+	}
+}
+
+func Test_RotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f := &RotatingFile{Path: path, MaxBytes: 100, MaxBackups: 2}
+	defer f.Close()
+
+	logger := NewAuditLogger(f)
+	for i := 0; i < 10; i++ {
+		logger.AfterMutation(context.Background(), Mutation{Zone: "example.com", Operation: OperationCreate, RecordID: i + 1}, nil)
+	}
+	if err := logger.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path + ".2"); err != nil {
+		t.Fatalf("expected 2 rotated files: %v", err)
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Fatal("expected at most 2 rotated files")
+	}
+
+	entries, err := ReadAuditFiles(path, AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("len(entries) != 3 => %d", len(entries))
+	}
+	for k, e := range entries {
+		if e.RecordID != k+8 {
+			t.Fatalf("entries[%d].RecordID != %d => %d", k, k+8, e.RecordID)
+		}
+	}
+}
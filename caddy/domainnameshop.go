@@ -0,0 +1,131 @@
+// Package domainnameshop is a Caddy module for managing DNS records hosted by
+// Domainname.shop, for example to solve ACME DNS challenges.
+package domainnameshop
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/libdns/domainnameshop"
+)
+
+// Provider lets Caddy read and manipulate DNS records hosted by Domainname.shop.
+type Provider struct {
+	*domainnameshop.Provider
+
+	// VerifyCredentials checks the credentials with the API while provisioning,
+	// so a bad token fails at startup instead of during a certificate challenge.
+	VerifyCredentials bool `json:"verify_credentials,omitempty"`
+}
+
+func init() {
+	caddy.RegisterModule(Provider{})
+}
+
+// CaddyModule returns the Caddy module information.
+func (Provider) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "dns.providers.domainnameshop",
+		New: func() caddy.Module { return &Provider{Provider: new(domainnameshop.Provider)} },
+	}
+}
+
+// Provision replaces placeholders, like {env.DOMAINNAMESHOP_TOKEN}, in the credentials and base URL.
+func (p *Provider) Provision(ctx caddy.Context) error {
+	repl := caddy.NewReplacer()
+	p.Provider.APIToken = repl.ReplaceAll(p.Provider.APIToken, "")
+	p.Provider.APISecret = repl.ReplaceAll(p.Provider.APISecret, "")
+	p.Provider.BaseURL = repl.ReplaceAll(p.Provider.BaseURL, "")
+
+	if p.VerifyCredentials {
+		if _, err := p.Provider.Verify(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCaddyfile sets up the DNS provider from Caddyfile tokens. Syntax:
+//
+//	domainnameshop [<api_token> <api_secret>] {
+//	    api_token <api_token>
+//	    api_secret <api_secret>
+//	    default_ttl <duration>
+//	    base_url <url>
+//	    max_retries <count>
+//	    verify_credentials
+//	}
+func (p *Provider) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	for d.Next() {
+		switch args := d.RemainingArgs(); len(args) {
+		case 0:
+		case 2:
+			p.Provider.APIToken, p.Provider.APISecret = args[0], args[1]
+		default:
+			return d.ArgErr()
+		}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			option := d.Val()
+			if option == "verify_credentials" {
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+				p.VerifyCredentials = true
+				continue
+			}
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			value := d.Val()
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+
+			switch option {
+			case "api_token":
+				if p.Provider.APIToken != "" {
+					return d.Err("API token already set")
+				}
+				p.Provider.APIToken = value
+			case "api_secret":
+				if p.Provider.APISecret != "" {
+					return d.Err("API secret already set")
+				}
+				p.Provider.APISecret = value
+			case "default_ttl":
+				ttl, err := caddy.ParseDuration(value)
+				if err != nil {
+					return d.Errf("invalid default_ttl %q: %v", value, err)
+				}
+				if ttl <= 0 || ttl%time.Minute != 0 {
+					return d.Errf("default_ttl must be a positive number of whole minutes, got %s", value)
+				}
+				p.Provider.DefaultTTL = ttl
+			case "base_url":
+				p.Provider.BaseURL = value
+			case "max_retries":
+				retries, err := strconv.Atoi(value)
+				if err != nil || retries < 0 {
+					return d.Errf("invalid max_retries %q", value)
+				}
+				p.Provider.MaxRetries = retries
+			default:
+				return d.Errf("unrecognized subdirective '%s'", option)
+			}
+		}
+	}
+
+	if p.Provider.APIToken == "" || p.Provider.APISecret == "" {
+		return d.Err("missing API token or secret")
+	}
+	return nil
+}
+
+// Interface guards
+var (
+	_ caddyfile.Unmarshaler = (*Provider)(nil)
+	_ caddy.Provisioner     = (*Provider)(nil)
+)
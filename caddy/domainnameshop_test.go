@@ -0,0 +1,113 @@
+package domainnameshop
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/libdns/domainnameshop"
+)
+
+func newProvider() *Provider {
+	return &Provider{Provider: new(domainnameshop.Provider)}
+}
+
+func TestUnmarshalCaddyfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *domainnameshop.Provider
+	}{
+		{
+			name:     "inline credentials",
+			input:    `domainnameshop token secret`,
+			expected: &domainnameshop.Provider{APIToken: "token", APISecret: "secret"},
+		},
+		{
+			name: "block",
+			input: `domainnameshop {
+				api_token {env.DOMAINNAMESHOP_TOKEN}
+				api_secret {env.DOMAINNAMESHOP_SECRET}
+				default_ttl 5m
+				base_url http://localhost:8080/v0
+				max_retries 3
+				verify_credentials
+			}`,
+			expected: &domainnameshop.Provider{
+				APIToken:   "{env.DOMAINNAMESHOP_TOKEN}",
+				APISecret:  "{env.DOMAINNAMESHOP_SECRET}",
+				DefaultTTL: 5 * time.Minute,
+				BaseURL:    "http://localhost:8080/v0",
+				MaxRetries: 3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newProvider()
+			if err := p.UnmarshalCaddyfile(caddyfile.NewTestDispenser(test.input)); err != nil {
+				t.Fatalf("UnmarshalCaddyfile failed => %v", err)
+			}
+			got := p.Provider
+			if p.VerifyCredentials != strings.Contains(test.input, "verify_credentials") {
+				t.Fatalf("VerifyCredentials != %v", !p.VerifyCredentials)
+			}
+			if got.APIToken != test.expected.APIToken || got.APISecret != test.expected.APISecret ||
+				got.DefaultTTL != test.expected.DefaultTTL || got.BaseURL != test.expected.BaseURL ||
+				got.MaxRetries != test.expected.MaxRetries {
+				t.Fatalf("provider != expected => %+v != %+v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestUnmarshalCaddyfileErrors(t *testing.T) {
+	for _, input := range []string{
+		`domainnameshop`,
+		`domainnameshop token`,
+		`domainnameshop token secret extra`,
+		`domainnameshop token secret {
+			api_token other
+		}`,
+		`domainnameshop {
+			api_token token
+		}`,
+		`domainnameshop token secret {
+			default_ttl 90s
+		}`,
+		`domainnameshop token secret {
+			max_retries -1
+		}`,
+		`domainnameshop token secret {
+			base_url
+		}`,
+		`domainnameshop token secret {
+			unknown value
+		}`,
+		`domainnameshop token secret {
+			verify_credentials yes
+		}`,
+	} {
+		if err := newProvider().UnmarshalCaddyfile(caddyfile.NewTestDispenser(input)); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestProvision(t *testing.T) {
+	t.Setenv("DOMAINNAMESHOP_TOKEN", "token")
+	t.Setenv("DOMAINNAMESHOP_SECRET", "secret")
+
+	p := newProvider()
+	p.Provider.APIToken = "{env.DOMAINNAMESHOP_TOKEN}"
+	p.Provider.APISecret = "{env.DOMAINNAMESHOP_SECRET}"
+	if err := p.Provision(caddy.Context{}); err != nil {
+		t.Fatalf("Provision failed => %v", err)
+	}
+	if p.Provider.APIToken != "token" || p.Provider.APISecret != "secret" {
+		t.Fatalf("placeholders not replaced => %q, %q", p.Provider.APIToken, p.Provider.APISecret)
+	}
+}
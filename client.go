@@ -19,10 +19,44 @@ const defaultBaseURL string = "https://api.domeneshop.no/v0"
 // The api specifies that TTL must be in seconds but also in must multiples of 60
 const defaultTtl = time.Duration(2 * time.Minute)
 
-func (p *Provider) doRequest(token string, secret string, request *http.Request, result any) error {
+func (p *Provider) baseURL() string {
+	if p.BaseURL != "" {
+		return strings.TrimSuffix(p.BaseURL, "/")
+	}
+	return defaultBaseURL
+}
+
+// defaultTTL is the TTL of records created or updated without one.
+func (p *Provider) defaultTTL() time.Duration {
+	if p.DefaultTTL > 0 {
+		return p.DefaultTTL
+	}
+	return defaultTtl
+}
+
+// APIError is an error status returned by the API.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("got error status: HTTP %d: %+v", e.StatusCode, e.Body)
+}
+
+// doRequest sends the request, with the retries of sendWithRetries. route is the path
+// template of the request for tracing, like /domains/{id}/dns.
+func (p *Provider) doRequest(token string, secret string, request *http.Request, route string, result any) (err error) {
+	endpoint := request.Method + " " + route
+	ctx, span := p.startSpan(request.Context(), endpoint,
+		Attribute{AttrHTTPMethod, request.Method},
+		Attribute{AttrHTTPRoute, route},
+	)
+	defer func() { endSpan(span, err) }()
+	request = request.WithContext(ctx)
+
 	request.SetBasicAuth(token, secret)
-	client := &http.Client{}
-	response, err := client.Do(request)
+	response, err := p.sendWithRetries(ctx, span, endpoint, request)
 	if err != nil {
 		return err
 	}
@@ -30,11 +64,12 @@ func (p *Provider) doRequest(token string, secret string, request *http.Request,
 
 	if response.StatusCode >= 400 {
 		body, _ := io.ReadAll(response.Body)
-		return fmt.Errorf("got error status: HTTP %d: %+v", response.StatusCode, string(body))
+		return &APIError{StatusCode: response.StatusCode, Body: string(body)}
 	}
 
 	if result != nil {
-		if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		// Some endpoints answer with 204 No Content
+		if err = json.NewDecoder(response.Body).Decode(&result); err != nil && err != io.EOF {
 			return err
 		}
 	}
@@ -42,34 +77,68 @@ func (p *Provider) doRequest(token string, secret string, request *http.Request,
 	return nil
 }
 
-func (p *Provider) getDomainInfo(ctx context.Context, token string, secret string, zone string) (dsZone, error) {
+func (p *Provider) getDomainInfo(ctx context.Context, token string, secret string, zone string) (Domain, error) {
 	p.zonesMu.Lock()
 	defer p.zonesMu.Unlock()
 	// if we already got the zone info, reuse it
 	if p.zones == nil {
-		p.zones = make(map[string]dsZone)
+		p.zones = make(map[string]Domain)
 	}
-	if zone, ok := p.zones[zone]; ok {
-		return zone, nil
+	key := zoneKey(zone)
+	if domain, ok := p.zones[key]; ok {
+		p.cacheLookup(ctx, ZoneCache, true)
+		return domain, nil
 	}
+	p.cacheLookup(ctx, ZoneCache, false)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(defaultBaseURL+"/domains?domain=%s", url.QueryEscape(removeFQDNTrailingDot(zone))), nil)
+	domains, err := p.listDomains(ctx, token, secret, key)
 	if err != nil {
-		return dsZone{}, err
+		return Domain{}, err
 	}
 
-	var zones []dsZone
-	err = p.doRequest(token, secret, req, &zones)
+	// The filter matches parts of names, so example.com also finds myexample.com
+	for _, domain := range domains {
+		if zoneKey(domain.Name) == key {
+			p.zones[key] = domain
+			return domain, nil
+		}
+	}
+	return Domain{}, fmt.Errorf("zone %s not found, got %d other domains", zone, len(domains))
+}
+
+// listDomains returns the domains in the account, optionally only those whose name contains filter.
+// Callers are responsible for caching the result.
+func (p *Provider) listDomains(ctx context.Context, token string, secret string, filter string) ([]Domain, error) {
+	reqURL := p.baseURL() + "/domains"
+	if filter != "" {
+		reqURL += "?domain=" + url.QueryEscape(filter)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
-		return dsZone{}, err
+		return nil, err
 	}
 
-	if len(zones) != 1 {
-		return dsZone{}, fmt.Errorf("expected 1 zone, got %d for %s", len(zones), zone)
+	var domains []Domain
+	err = p.doRequest(token, secret, req, "/domains", &domains)
+	if err != nil {
+		return nil, err
 	}
-	p.zones[zone] = zones[0]
 
-	return zones[0], nil
+	return domains, nil
+}
+
+// cacheDomains stores the domains in the zone cache, so later lookups don't need a request.
+// complete tells that they are all domains of the account.
+func (p *Provider) cacheDomains(domains []Domain, complete bool) {
+	p.zonesMu.Lock()
+	defer p.zonesMu.Unlock()
+	if p.zones == nil {
+		p.zones = make(map[string]Domain)
+	}
+	p.zonesComplete = p.zonesComplete || complete
+	for _, domain := range domains {
+		p.zones[zoneKey(domain.Name)] = domain
+	}
 }
 
 func (p *Provider) getAllDomainRecords(ctx context.Context, token string, secret string, zone string) ([]dsDNSRecord, error) {
@@ -78,39 +147,116 @@ func (p *Provider) getAllDomainRecords(ctx context.Context, token string, secret
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(defaultBaseURL+"/domains/%d/dns", domain.ID), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(p.baseURL()+"/domains/%d/dns", domain.ID), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var result []dsDNSRecord
-	err = p.doRequest(token, secret, req, &result)
+	err = p.doRequest(token, secret, req, "/domains/{id}/dns", &result)
 	if err != nil {
 		return nil, err
 	}
 
+	for i := range result {
+		if result[i].Host == "" {
+			result[i].Host = "@"
+		}
+	}
+
 	// Save the records for later
 	p.knownRecordsMu.Lock()
 	defer p.knownRecordsMu.Unlock()
 	if p.knownRecords == nil {
 		p.knownRecords = make(map[string][]dsDNSRecord)
 	}
-	p.knownRecords[zone] = result
+	p.knownRecords[zoneKey(zone)] = result
 
 	return result, nil
 }
 
+func (p *Provider) getForwards(ctx context.Context, token string, secret string, zone string) ([]Forward, error) {
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(p.baseURL()+"/domains/%d/forwards/", domain.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Forward
+	err = p.doRequest(token, secret, req, "/domains/{id}/forwards/", &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (p *Provider) createForward(ctx context.Context, token string, secret string, zone string, forward Forward) error {
+	return p.sendForward(ctx, token, secret, zone, "POST", "", forward)
+}
+
+func (p *Provider) updateForward(ctx context.Context, token string, secret string, zone string, forward Forward) error {
+	return p.sendForward(ctx, token, secret, zone, "PUT", forward.Host, forward)
+}
+
+func (p *Provider) deleteForward(ctx context.Context, token string, secret string, zone string, forward Forward) error {
+	return p.sendForward(ctx, token, secret, zone, "DELETE", forward.Host, Forward{})
+}
+
+func (p *Provider) sendForward(ctx context.Context, token string, secret string, zone string, method string, host string, forward Forward) error {
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return err
+	}
+
+	reqURL := fmt.Sprintf(p.baseURL()+"/domains/%d/forwards/%s", domain.ID, url.PathEscape(host))
+	var body io.Reader
+	if method != "DELETE" {
+		reqBuffer, err := json.Marshal(forward)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(reqBuffer)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	route := "/domains/{id}/forwards/"
+	if host != "" {
+		route += "{host}"
+	}
+	return p.doRequest(token, secret, req, route, nil)
+}
+
 // Get a dns record from zone
 // Retrieving records directly require an ID, since we dont' really have that ahead of time we can only really rely on getting the whole zone
 // We try to cache results to reduce the need for queries
 func (p *Provider) getDNSRecord(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) (dsDNSRecord, error) {
+	if record.Host != "" {
+		var err error
+		if record.Host, err = apiHost(record.Host, zone); err != nil {
+			return dsDNSRecord{}, err
+		}
+	}
+
 	// Try to retrieve from our cached records first
 	var dsrecord = p.getRecordFromKnownRecords(record, zone)
 
 	// if it's not an emtpy struct we return it
 	if (dsDNSRecord{}) != dsrecord {
+		p.cacheLookup(ctx, RecordCache, true)
 		return dsrecord, nil
 	}
+	p.cacheLookup(ctx, RecordCache, false)
 
 	// Fall back to getting the full zone info
 	_, err := p.getAllDomainRecords(ctx, token, secret, zone)
@@ -124,11 +270,6 @@ func (p *Provider) getDNSRecord(ctx context.Context, token string, secret string
 }
 
 func (p *Provider) deleteDNSRecord(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) error {
-	domain, err := p.getDomainInfo(ctx, token, secret, zone)
-	if err != nil {
-		return err
-	}
-
 	// Try to retrieve from our cached records first
 	dsrecord, err := p.getDNSRecord(ctx, token, secret, zone, record)
 	if err != nil {
@@ -139,38 +280,67 @@ func (p *Provider) deleteDNSRecord(ctx context.Context, token string, secret str
 		return nil
 	}
 
-	reqURL := fmt.Sprintf(defaultBaseURL+"/domains/%d/dns/%d", domain.ID, dsrecord.ID)
+	return p.deleteDNSRecordByID(ctx, token, secret, zone, dsrecord)
+}
+
+// deleteDNSRecordByID deletes the record with the ID of record, without looking it up first.
+func (p *Provider) deleteDNSRecordByID(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) (err error) {
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return err
+	}
+
+	mutation := newMutation(zone, OperationDelete, record, dsDNSRecord{})
+	if err := p.runBeforeHooks(ctx, mutation); err != nil {
+		return err
+	}
+	defer func() { p.runAfterHooks(ctx, mutation, err) }()
+
+	reqURL := fmt.Sprintf(p.baseURL()+"/domains/%d/dns/%d", domain.ID, record.ID)
 	req, err := http.NewRequestWithContext(ctx, "DELETE", reqURL, nil)
 	if err != nil {
 		return err
 	}
 
-	err = p.doRequest(token, secret, req, nil)
+	err = p.doRequest(token, secret, req, "/domains/{id}/dns/{recordId}", nil)
 	if err != nil {
 		return err
 	}
-	_ = p.removeRecordFromKnownRecords(dsrecord, zone)
+	_ = p.removeRecordFromKnownRecords(record, zone)
 	return nil
 }
 
-func (p *Provider) createDNSRecord(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) (dsDNSRecord, error) {
+func (p *Provider) createDNSRecord(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) (created dsDNSRecord, err error) {
 	domain, err := p.getDomainInfo(ctx, token, secret, zone)
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
 
-	record.Host = normalizeRecordName(record.Host, zone)
+	if record.Host, err = apiHost(record.Host, zone); err != nil {
+		return dsDNSRecord{}, err
+	}
+
+	mutation := newMutation(zone, OperationCreate, dsDNSRecord{}, record)
+	if err := p.runBeforeHooks(ctx, mutation); err != nil {
+		return dsDNSRecord{}, err
+	}
+	defer func() {
+		if err == nil {
+			mutation = newMutation(zone, OperationCreate, dsDNSRecord{}, created)
+		}
+		p.runAfterHooks(ctx, mutation, err)
+	}()
 
 	reqData := record
 	if reqData.TTL == 0 {
-		reqData.TTL = int(defaultTtl.Seconds())
+		reqData.TTL = int(p.defaultTTL().Seconds())
 	}
 	reqBuffer, err := json.Marshal(reqData)
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
 
-	reqURL := fmt.Sprintf(defaultBaseURL+"/domains/%d/dns", domain.ID)
+	reqURL := fmt.Sprintf(p.baseURL()+"/domains/%d/dns", domain.ID)
 	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(reqBuffer))
 	if err != nil {
 		return dsDNSRecord{}, err
@@ -178,45 +348,65 @@ func (p *Provider) createDNSRecord(ctx context.Context, token string, secret str
 	req.Header.Set("Content-Type", "application/json")
 
 	var result dsDNSRecord
-	err = p.doRequest(token, secret, req, &result)
+	err = p.doRequest(token, secret, req, "/domains/{id}/dns", &result)
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
 	// Add the ID to the incoming record
 	record.ID = result.ID
+	cached := reqData
+	cached.ID = result.ID
+	p.addRecordToKnownRecords(cached, zone)
 
 	return record, nil
 }
 
-func (p *Provider) updateDNSRecord(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) (dsDNSRecord, error) {
+func (p *Provider) updateDNSRecord(ctx context.Context, token string, secret string, zone string, record dsDNSRecord) (updated dsDNSRecord, err error) {
 	domain, err := p.getDomainInfo(ctx, token, secret, zone)
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
 
-	reqData := record
-	if reqData.TTL == 0 {
-		reqData.TTL = int(defaultTtl.Seconds())
+	if record.Host, err = apiHost(record.Host, zone); err != nil {
+		return dsDNSRecord{}, err
 	}
-	reqBuffer, err := json.Marshal(reqData)
+	if record.TTL == 0 {
+		record.TTL = int(p.defaultTTL().Seconds())
+	}
+
+	var before dsDNSRecord
+	if len(p.Hooks) > 0 {
+		// Hooks get the old state of the record, which usually is cached already
+		before, err = p.getDNSRecord(ctx, token, secret, zone, dsDNSRecord{ID: record.ID})
+		if err != nil {
+			return dsDNSRecord{}, err
+		}
+	}
+	mutation := newMutation(zone, OperationUpdate, before, record)
+	if err := p.runBeforeHooks(ctx, mutation); err != nil {
+		return dsDNSRecord{}, err
+	}
+	defer func() { p.runAfterHooks(ctx, mutation, err) }()
+	reqBuffer, err := json.Marshal(record)
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
 
-	reqURL := fmt.Sprintf(defaultBaseURL+"/domains/%d/dns/%d", domain.ID, record.ID)
-	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(reqBuffer))
+	reqURL := fmt.Sprintf(p.baseURL()+"/domains/%d/dns/%d", domain.ID, record.ID)
+	req, err := http.NewRequestWithContext(ctx, "PUT", reqURL, bytes.NewBuffer(reqBuffer))
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	var result dsDNSRecord
-	err = p.doRequest(token, secret, req, &result)
+	// The API answers with 204 No Content, so the record we sent is the result
+	err = p.doRequest(token, secret, req, "/domains/{id}/dns/{recordId}", nil)
 	if err != nil {
 		return dsDNSRecord{}, err
 	}
-	// We don't actually get an result from the API so we query for update
-	return p.getDNSRecord(ctx, token, secret, zone, record)
+	p.updateRecordInKnownRecords(record, zone)
+
+	return record, nil
 }
 
 func (p *Provider) createOrUpdateDNSRecord(ctx context.Context, token string, secret string, zone string, r dsDNSRecord) (dsDNSRecord, error) {
@@ -227,6 +417,42 @@ func (p *Provider) createOrUpdateDNSRecord(ctx context.Context, token string, se
 	return p.updateDNSRecord(ctx, token, secret, zone, r)
 }
 
+// findRecordToSet returns the record of zone that SetRecords updates with record, which has
+// no ID: one with the same host and type that isn't claimed by an earlier record of the same
+// call, preferring one with the same data. It returns an empty record if there is none.
+func (p *Provider) findRecordToSet(ctx context.Context, token string, secret string, zone string, record dsDNSRecord, claimed map[int]bool) (dsDNSRecord, error) {
+	host, err := apiHost(record.Host, zone)
+	if err != nil {
+		return dsDNSRecord{}, err
+	}
+
+	key := zoneKey(zone)
+	p.knownRecordsMu.Lock()
+	_, cached := p.knownRecords[key]
+	p.knownRecordsMu.Unlock()
+	if !cached {
+		if _, err := p.getAllDomainRecords(ctx, token, secret, zone); err != nil {
+			return dsDNSRecord{}, err
+		}
+	}
+
+	p.knownRecordsMu.Lock()
+	defer p.knownRecordsMu.Unlock()
+	var found dsDNSRecord
+	for _, rec := range p.knownRecords[key] {
+		if rec.ID == 0 || claimed[rec.ID] || rec.Type != record.Type || !strings.EqualFold(rec.Host, host) {
+			continue
+		}
+		if rec.Data == record.Data {
+			return rec, nil
+		}
+		if found.ID == 0 {
+			found = rec
+		}
+	}
+	return found, nil
+}
+
 func (p *Provider) getRecordFromKnownRecords(record dsDNSRecord, zone string) dsDNSRecord {
 	p.knownRecordsMu.Lock()
 	defer p.knownRecordsMu.Unlock()
@@ -234,11 +460,15 @@ func (p *Provider) getRecordFromKnownRecords(record dsDNSRecord, zone string) ds
 		p.knownRecords = make(map[string][]dsDNSRecord)
 	}
 
-	if zoneRecords, ok := p.knownRecords[zone]; ok {
+	key := zoneKey(zone)
+	if zoneRecords, ok := p.knownRecords[key]; ok {
 		for _, rec := range zoneRecords {
-			if record.ID == rec.ID && record.ID != 0 && rec.ID != 0 {
-				return rec
-			} else if record.Host == rec.Host && record.Data == rec.Data && rec.ID != 0 {
+			if record.ID != 0 {
+				// Records with an ID only match by it, others may have the same content
+				if record.ID == rec.ID {
+					return rec
+				}
+			} else if strings.EqualFold(record.Host, rec.Host) && record.Data == rec.Data && rec.ID != 0 {
 				return rec
 			}
 		}
@@ -253,10 +483,11 @@ func (p *Provider) removeRecordFromKnownRecords(record dsDNSRecord, zone string)
 		p.knownRecords = make(map[string][]dsDNSRecord)
 	}
 
-	if zoneRecords, ok := p.knownRecords[zone]; ok {
+	key := zoneKey(zone)
+	if zoneRecords, ok := p.knownRecords[key]; ok {
 		for i, rec := range zoneRecords {
 			if record.ID == rec.ID && record.ID != 0 {
-				p.knownRecords[zone][i].ID = 0
+				p.knownRecords[key][i].ID = 0
 				return true
 			}
 		}
@@ -264,33 +495,36 @@ func (p *Provider) removeRecordFromKnownRecords(record dsDNSRecord, zone string)
 	return false
 }
 
-// func (p *Provider) updateRecordInKnownRecords(dsZoneRecords dsZoneRecord, dsRecord dsDNSRecord, zone string) {
-// 	p.knownRecordsMu.Lock()
-// 	defer p.knownRecordsMu.Unlock()
-// 	if p.knownRecords == nil {
-// 		p.knownRecords = make(map[string][]dsDNSRecord)
-// 	}
-// 	if zoneRecords, ok := p.knownRecords[zone]; ok {
-// 		for _, rec := range zoneRecords {
-// 			if record.ID == rec.ID {
-// 				return rec
-// 			} else if record.Host == rec.Host && record.Data == record.Data {
-// 				return rec
-// 			}
-// 		}
-// 	}
-// 	p.knownRecords[dsZoneRecords] = dsRecord
-// }
+// addRecordToKnownRecords adds a created record to the known records of zone, if they
+// were fetched already. Otherwise it's in them when they are.
+func (p *Provider) addRecordToKnownRecords(record dsDNSRecord, zone string) {
+	p.knownRecordsMu.Lock()
+	defer p.knownRecordsMu.Unlock()
 
-func removeFQDNTrailingDot(fqdn string) string {
-	return strings.TrimSuffix(fqdn, ".")
+	key := zoneKey(zone)
+	if zoneRecords, ok := p.knownRecords[key]; ok {
+		p.knownRecords[key] = append(zoneRecords, record)
+	}
 }
 
-func normalizeRecordName(recordName string, zone string) string {
-	normalized := removeFQDNTrailingDot(recordName)
-	normalized = strings.TrimSuffix(normalized, removeFQDNTrailingDot(zone))
-	if normalized == "" {
-		normalized = "@"
+func (p *Provider) updateRecordInKnownRecords(record dsDNSRecord, zone string) {
+	p.knownRecordsMu.Lock()
+	defer p.knownRecordsMu.Unlock()
+	if p.knownRecords == nil {
+		p.knownRecords = make(map[string][]dsDNSRecord)
 	}
-	return removeFQDNTrailingDot(normalized)
+
+	key := zoneKey(zone)
+	if zoneRecords, ok := p.knownRecords[key]; ok {
+		for i, rec := range zoneRecords {
+			if record.ID == rec.ID && record.ID != 0 {
+				p.knownRecords[key][i] = record
+				return
+			}
+		}
+	}
+}
+
+func removeFQDNTrailingDot(fqdn string) string {
+	return strings.TrimSuffix(fqdn, ".")
 }
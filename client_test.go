@@ -0,0 +1,50 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_updateDNSRecord(t *testing.T) {
+	var method, path string
+	var sent dsDNSRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/domains":
+			json.NewEncoder(w).Encode([]Domain{{ID: 1, Name: "example.com"}})
+		case r.Method == "GET" && r.URL.Path == "/domains/1/dns":
+			json.NewEncoder(w).Encode([]dsDNSRecord{{ID: 10, Host: "www", Type: "A", Data: "192.0.2.1", TTL: 3600}})
+		default:
+			method, path = r.Method, r.URL.Path
+			json.NewDecoder(r.Body).Decode(&sent)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	p := &Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}
+
+	if _, err := p.getAllDomainRecords(context.Background(), "token", "secret", "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	record := dsDNSRecord{ID: 10, Host: "www", Type: "A", Data: "192.0.2.2", TTL: 600}
+	updated, err := p.updateDNSRecord(context.Background(), "token", "secret", "example.com", record)
+	if err != nil {
+		t.Fatalf("updateDNSRecord failed => %v", err)
+	}
+
+	if method != "PUT" || path != "/domains/1/dns/10" {
+		t.Fatalf("update sent as %s %s", method, path)
+	}
+	if sent.Data != "192.0.2.2" || sent.TTL != 600 {
+		t.Fatalf("unexpected request body => %+v", sent)
+	}
+	if updated != record {
+		t.Fatalf("updated != record => %+v", updated)
+	}
+	if cached := p.getRecordFromKnownRecords(dsDNSRecord{ID: 10}, "example.com"); cached.Data != "192.0.2.2" {
+		t.Fatalf("known records not updated => %+v", cached)
+	}
+}
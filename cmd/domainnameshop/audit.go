@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/libdns/domainnameshop"
+)
+
+func runAudit(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	path := flags.String("log", os.Getenv("LIBDNS_DOMAINNAMESHOP_AUDIT_LOG"), "audit log `file` to query")
+	zone := flags.String("zone", "", "only show changes in this `zone`")
+	name := flags.String("name", "", "only show changes of records with this `name`")
+	since := flags.String("since", "", "only show changes at or after this `time`, RFC 3339, a date or a duration ago like 24h")
+	until := flags.String("until", "", "only show changes before this `time`")
+	output := flags.String("output", outputTable, "output `format`: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("-log or LIBDNS_DOMAINNAMESHOP_AUDIT_LOG is required")
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON); err != nil {
+		return err
+	}
+
+	q := domainnameshop.AuditQuery{Zone: *zone, Name: *name}
+	var err error
+	if q.Since, err = parseQueryTime(*since); err != nil {
+		return err
+	}
+	if q.Until, err = parseQueryTime(*until); err != nil {
+		return err
+	}
+
+	entries, err := domainnameshop.ReadAuditFiles(*path, q)
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		if entries == nil {
+			entries = []domainnameshop.AuditEntry{}
+		}
+		return writeJSON(stdout, entries)
+	}
+
+	format := func(r *domainnameshop.AuditRecord) string {
+		if r == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s %s %s", r.Name, r.Type, r.Data)
+	}
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		outcome := e.Outcome
+		if e.Error != "" {
+			outcome += ": " + e.Error
+		}
+		rows = append(rows, []string{
+			e.Time.Local().Format(time.DateTime),
+			e.Actor,
+			e.Zone,
+			string(e.Operation),
+			strconv.Itoa(e.RecordID),
+			format(e.Before),
+			format(e.After),
+			outcome,
+		})
+	}
+	return writeTable(stdout, []string{"TIME", "ACTOR", "ZONE", "OPERATION", "ID", "BEFORE", "AFTER", "OUTCOME"}, rows)
+}
+
+// parseQueryTime parses an RFC 3339 time, a date or a duration before now. Empty is the zero time.
+func parseQueryTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, YYYY-MM-DD or a duration", s)
+}
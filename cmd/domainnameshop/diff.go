@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/libdns/domainnameshop"
+)
+
+const outputUnified = "unified"
+
+func runDiff(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", "", "snapshot `file` to compare from")
+	to := flags.String("to", "", "snapshot `file` to compare to, the live zone if empty")
+	zone := flags.String("zone", "", "compare the latest snapshot of this `zone` in -dir with the live zone")
+	dir := flags.String("dir", "", "backup `directory` used with -zone")
+	output := flags.String("output", "text", "output `format`: text, unified or json")
+	exitCode := flags.Bool("exit-code", false, "exit with status 3 if there are changes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*from == "") == (*zone == "" || *dir == "") {
+		return errors.New("either -from or -zone and -dir are required")
+	}
+	if err := checkOutputFormat(*output, "text", outputUnified, outputJSON); err != nil {
+		return err
+	}
+
+	var before *domainnameshop.Snapshot
+	var err error
+	if *from != "" {
+		before, err = domainnameshop.LoadSnapshot(*from)
+	} else {
+		before, err = (&domainnameshop.BackupStore{Dir: *dir}).Latest(*zone)
+	}
+	if err != nil {
+		return err
+	}
+
+	var after *domainnameshop.Snapshot
+	if *to != "" {
+		after, err = domainnameshop.LoadSnapshot(*to)
+	} else {
+		if err := requireCredentials(p); err != nil {
+			return err
+		}
+		after, err = p.Snapshot(ctx, before.Zone)
+	}
+	if err != nil {
+		return err
+	}
+
+	diff := domainnameshop.DiffSnapshots(before, after)
+	switch *output {
+	case outputJSON:
+		err = writeJSON(stdout, diff)
+	case outputUnified:
+		err = domainnameshop.WriteUnifiedDiff(stdout, before, after)
+	default:
+		err = diff.WriteText(stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *exitCode && !diff.Empty() {
+		return exitError(3)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/domainnameshop"
+)
+
+// expiryExitCode is the exit status used with -exit-code when any domain needs attention.
+const expiryExitCode = 3
+
+func runExpiry(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("expiry", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	days := flags.Int("days", 30, "report domains expiring within this many `days`")
+	output := flags.String("output", outputTable, "output `format`: table, json or none")
+	exitCode := flags.Bool("exit-code", false, "exit with status 3 if any domain needs attention")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON, "none"); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	findings, err := p.CheckExpiry(ctx, *days)
+	if err != nil {
+		return err
+	}
+
+	switch *output {
+	case outputJSON:
+		if findings == nil {
+			findings = []domainnameshop.ExpiryFinding{}
+		}
+		err = writeJSON(stdout, findings)
+	case outputTable:
+		rows := make([][]string, 0, len(findings))
+		for _, f := range findings {
+			issues := make([]string, 0, len(f.Issues))
+			for _, issue := range f.Issues {
+				issues = append(issues, string(issue))
+			}
+			expiry := "-"
+			if !f.Domain.ExpiryDate.IsZero() {
+				expiry = f.Domain.ExpiryDate.Format("2006-01-02")
+			}
+			rows = append(rows, []string{
+				f.Domain.Name,
+				expiry,
+				strconv.Itoa(f.DaysLeft),
+				strconv.FormatBool(f.Domain.Renew),
+				string(f.Domain.Status),
+				strings.Join(issues, ","),
+			})
+		}
+		err = writeTable(stdout, []string{"DOMAIN", "EXPIRES", "DAYS", "RENEW", "STATUS", "ISSUES"}, rows)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *exitCode && len(findings) > 0 {
+		return exitError(expiryExitCode)
+	}
+	return nil
+}
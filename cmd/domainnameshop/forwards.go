@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/libdns/domainnameshop"
+)
+
+func runForwards(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintf(stderr, "Usage: domainnameshop forwards list -zone <zone> [flags]\n")
+		return flag.ErrHelp
+	}
+
+	flags := flag.NewFlagSet("forwards list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	zone := flags.String("zone", "", "the `zone` to list forwards for")
+	name := flags.String("name", "", "only list the forward for this `host`")
+	output := flags.String("output", outputTable, "output `format`: table or json")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *zone == "" {
+		return errors.New("-zone is required")
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON); err != nil {
+		return err
+	}
+	host := ""
+	if *name != "" {
+		var err error
+		if host, err = domainnameshop.RelativeName(*name, *zone); err != nil {
+			return err
+		}
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	forwards, err := p.ListForwards(ctx, *zone)
+	if err != nil {
+		return err
+	}
+
+	filtered := []domainnameshop.Forward{}
+	for _, f := range forwards {
+		if host == "" || f.Host == host {
+			filtered = append(filtered, f)
+		}
+	}
+
+	if *output == outputJSON {
+		return writeJSON(stdout, filtered)
+	}
+
+	rows := make([][]string, 0, len(filtered))
+	for _, f := range filtered {
+		rows = append(rows, []string{f.Host, f.URL, strconv.FormatBool(f.Frame)})
+	}
+	return writeTable(stdout, []string{"HOST", "URL", "FRAME"}, rows)
+}
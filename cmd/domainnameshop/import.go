@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/libdns/domainnameshop"
+	"github.com/libdns/libdns"
+)
+
+func runImport(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	zone := flags.String("zone", "", "the `zone` to import into")
+	file := flags.String("file", "", "zone `file` to import, $INCLUDE paths are relative to its directory")
+	prune := flags.Bool("prune", false, "delete records that aren't in the zone file")
+	dryRun := flags.Bool("dry-run", false, "only show the changes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *zone == "" || *file == "" {
+		return errors.New("-zone and -file are required")
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, warnings, err := domainnameshop.ParseZoneFile(f, *zone, &domainnameshop.ZoneFileOptions{
+		Include: os.DirFS(filepath.Dir(*file)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(stderr, "warning: %s\n", w)
+	}
+
+	result, err := p.ImportZone(ctx, *zone, records, domainnameshop.ImportOptions{Prune: *prune, DryRun: *dryRun})
+	writeChanges(stdout, "+", result.Created)
+	writeChanges(stdout, "~", result.Updated)
+	writeChanges(stdout, "-", result.Deleted)
+	return err
+}
+
+func writeChanges(w io.Writer, prefix string, records []libdns.Record) {
+	for _, rec := range records {
+		rr := rec.RR()
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\n", prefix, rr.Name, rr.TTL, rr.Type, rr.Data)
+	}
+}
@@ -0,0 +1,170 @@
+// Command domainnameshop inspects and manages Domainname.shop domains from the command line.
+//
+// Credentials are read from LIBDNS_DOMAINNAMESHOP_TOKEN and LIBDNS_DOMAINNAMESHOP_SECRET,
+// from the files named by LIBDNS_DOMAINNAMESHOP_TOKEN_FILE and LIBDNS_DOMAINNAMESHOP_SECRET_FILE,
+// or given with the -token and -secret flags. LIBDNS_DOMAINNAMESHOP_BASE_URL or -base-url
+// point the tool at another API server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/libdns/domainnameshop"
+)
+
+// command is a subcommand of the tool.
+type command struct {
+	usage string
+	run   func(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"verify": {
+		usage: "check the credentials and show the services of each domain",
+		run:   runVerify,
+	},
+	"zones": {
+		usage: "list the domains in the account",
+		run:   runZones,
+	},
+	"get": {
+		usage: "show the details of a domain",
+		run:   runGet,
+	},
+	"records": {
+		usage: "list, add, set or delete DNS records",
+		run:   runRecords,
+	},
+	"import": {
+		usage: "import a BIND zone file into a zone",
+		run:   runImport,
+	},
+	"snapshot": {
+		usage: "snapshot the records and forwards of a zone",
+		run:   runSnapshot,
+	},
+	"restore": {
+		usage: "restore a zone from a snapshot",
+		run:   runRestore,
+	},
+	"diff": {
+		usage: "show record changes between snapshots or since a snapshot",
+		run:   runDiff,
+	},
+	"audit": {
+		usage: "query the audit log of changes",
+		run:   runAudit,
+	},
+	"sweep": {
+		usage: "delete stale ACME challenge records",
+		run:   runSweep,
+	},
+	"forwards": {
+		usage: "list HTTP forwards",
+		run:   runForwards,
+	},
+	"expiry": {
+		usage: "report domains that are expiring, not renewed or not active",
+		run:   runExpiry,
+	},
+}
+
+// exitError makes the tool exit with a specific status without printing an error.
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+var errNoCredentials = errors.New("LIBDNS_DOMAINNAMESHOP_TOKEN and LIBDNS_DOMAINNAMESHOP_SECRET (or -token and -secret) must be set")
+
+// requireCredentials is called by commands after parsing their flags, so -h works without credentials.
+func requireCredentials(p *domainnameshop.Provider) error {
+	if p.Credentials == nil && (p.APIToken == "" || p.APISecret == "") {
+		return errNoCredentials
+	}
+	return nil
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	cancel()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("domainnameshop", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	token := flags.String("token", os.Getenv("LIBDNS_DOMAINNAMESHOP_TOKEN"), "API `token`")
+	secret := flags.String("secret", os.Getenv("LIBDNS_DOMAINNAMESHOP_SECRET"), "API `secret`")
+	auditLog := flags.String("audit-log", os.Getenv("LIBDNS_DOMAINNAMESHOP_AUDIT_LOG"), "append changes to this audit log `file`")
+	actor := flags.String("actor", os.Getenv("USER"), "`name` recorded as the actor in the audit log")
+	baseURL := flags.String("base-url", os.Getenv("LIBDNS_DOMAINNAMESHOP_BASE_URL"), "API base `URL` (default https://api.domeneshop.no/v0)")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: domainnameshop [flags] <command> [command flags]\n\nCommands:\n")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %-10s %s\n", name, commands[name].usage)
+		}
+		fmt.Fprintf(stderr, "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	p := &domainnameshop.Provider{
+		APIToken:  *token,
+		APISecret: *secret,
+		BaseURL:   *baseURL,
+	}
+	if *token == "" && *secret == "" && (os.Getenv(domainnameshop.EnvToken+"_FILE") != "" || os.Getenv(domainnameshop.EnvSecret+"_FILE") != "") {
+		p.Credentials = domainnameshop.EnvCredentials{}
+	}
+	if *auditLog != "" && flags.Arg(0) != "audit" {
+		f := &domainnameshop.RotatingFile{Path: *auditLog, MaxBytes: 10 << 20, MaxBackups: 10}
+		defer f.Close()
+		p.Hooks = append(p.Hooks, domainnameshop.NewAuditLogger(f))
+		ctx = domainnameshop.WithActor(ctx, *actor)
+	}
+
+	err := cmd.run(ctx, p, flags.Args()[1:], stdout, stderr)
+	var exit exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		return int(exit)
+	case errors.Is(err, flag.ErrHelp), errors.Is(err, errNoCredentials):
+		if errors.Is(err, errNoCredentials) {
+			fmt.Fprintln(stderr, err)
+		}
+		return 2
+	default:
+		fmt.Fprintf(stderr, "domainnameshop %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+}
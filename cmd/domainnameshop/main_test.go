@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAPI serves the domain example.com with ID 1, its records and forwards, and keeps
+// the record changes made through it.
+type fakeAPI struct {
+	mu       sync.Mutex
+	records  []map[string]any
+	nextID   int
+	requests []string
+}
+
+var testDomain = map[string]any{
+	"id":              1,
+	"domain":          "example.com",
+	"expiry_date":     "2030-01-01",
+	"registered_date": "2020-01-01",
+	"nameservers":     []string{"ns1.hyp.net", "ns2.hyp.net"},
+	"registrant":      "Example AS",
+	"renew":           true,
+	"services":        map[string]any{"dns": true, "email": false, "registrar": true, "webhotel": "none"},
+	"status":          "active",
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *httptest.Server) {
+	api := &fakeAPI{
+		records: []map[string]any{
+			{"id": 10, "host": "www", "type": "A", "data": "192.0.2.1", "ttl": 3600},
+			{"id": 11, "host": "www", "type": "A", "data": "192.0.2.2", "ttl": 3600},
+			{"id": 12, "host": "txt", "type": "TXT", "data": "hello", "ttl": 3600},
+			{"id": 13, "host": "_acme-challenge", "type": "TXT", "data": "token", "ttl": 300},
+		},
+		nextID: 20,
+	}
+	server := httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func (a *fakeAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if r.Method != "GET" {
+		a.requests = append(a.requests, r.Method+" "+r.URL.Path)
+	}
+
+	id, recordPath := strings.CutPrefix(r.URL.Path, "/domains/1/dns/")
+	switch {
+	case r.URL.Path == "/domains":
+		json.NewEncoder(w).Encode([]map[string]any{testDomain})
+	case r.URL.Path == "/domains/1/forwards/":
+		json.NewEncoder(w).Encode([]map[string]any{{"host": "www", "frame": false, "url": "https://example.net"}})
+	case r.URL.Path == "/domains/1/dns" && r.Method == "GET":
+		json.NewEncoder(w).Encode(a.records)
+	case r.URL.Path == "/domains/1/dns" && r.Method == "POST":
+		var record map[string]any
+		json.NewDecoder(r.Body).Decode(&record)
+		record["id"] = a.nextID
+		a.records = append(a.records, record)
+		a.nextID++
+		json.NewEncoder(w).Encode(map[string]any{"id": record["id"]})
+	case recordPath:
+		for i, record := range a.records {
+			if strconv.Itoa(record["id"].(int)) != id {
+				continue
+			}
+			if r.Method == "DELETE" {
+				a.records = append(a.records[:i], a.records[i+1:]...)
+			} else {
+				json.NewDecoder(r.Body).Decode(&record)
+				record["id"], _ = strconv.Atoi(id)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_run(t *testing.T) {
+	for _, env := range []string{"TOKEN", "SECRET", "TOKEN_FILE", "SECRET_FILE", "BASE_URL", "AUDIT_LOG", "CHALLENGE_LEDGER"} {
+		t.Setenv("LIBDNS_DOMAINNAMESHOP_"+env, "")
+	}
+
+	snapshot := `{"version":1,"zone":"example.com","domain_id":1,"taken_at":"2026-10-18T12:00:00Z","records":[` +
+		`{"id":10,"host":"www","type":"A","ttl":3600,"data":"192.0.2.1"},` +
+		`{"id":12,"host":"txt","type":"TXT","ttl":3600,"data":"hello"},` +
+		`{"id":13,"host":"_acme-challenge","type":"TXT","ttl":300,"data":"token"}],` +
+		`"forwards":[{"host":"www","frame":false,"url":"https://example.net"}]}`
+
+	for _, c := range []struct {
+		name string
+		// args are given after the credential flags, {dir} is replaced by a directory
+		// with the files.
+		args          []string
+		files         map[string]string
+		noCredentials bool
+		code          int
+		stdout        []string
+		stderr        []string
+		requests      []string
+	}{
+		{name: "no command", code: 2, stderr: []string{"Usage: domainnameshop", "records"}},
+		{name: "unknown command", args: []string{"nope"}, code: 2, stderr: []string{`unknown command "nope"`}},
+		{name: "no credentials", args: []string{"zones"}, noCredentials: true, code: 2, stderr: []string{"must be set"}},
+		{name: "bad flag", args: []string{"-nope"}, code: 2, stderr: []string{"flag provided but not defined: -nope", "Usage: domainnameshop"}},
+		{name: "bad command flag", args: []string{"zones", "-nope"}, code: 1, stderr: []string{"flag provided but not defined: -nope", "Usage of zones"}},
+
+		{name: "verify", args: []string{"verify"}, stdout: []string{"DOMAIN", "example.com  true"}},
+		{name: "verify json", args: []string{"verify", "-output", "json"}, stdout: []string{`"domain": "example.com"`}},
+		{name: "zones", args: []string{"zones"}, stdout: []string{"ID", "1   example.com  active  2030-01-01"}},
+		{name: "zones output", args: []string{"zones", "-output", "xml"}, code: 1, stderr: []string{`unsupported output format "xml"`}},
+		{name: "get", args: []string{"get", "example.com"}, stdout: []string{"registrant   Example AS", "nameservers  ns1.hyp.net,ns2.hyp.net"}},
+		{name: "get without domain", args: []string{"get"}, code: 2, stderr: []string{"Usage: domainnameshop get"}},
+
+		{name: "records", args: []string{"records"}, code: 2, stderr: []string{"Usage: domainnameshop records"}},
+		{name: "records list", args: []string{"records", "list", "-zone", "example.com", "-type", "txt"}, stdout: []string{"txt", "hello"}},
+		{name: "records list zone", args: []string{"records", "list"}, code: 1, stderr: []string{"-zone is required"}},
+		{name: "records list name outside zone", args: []string{"records", "list", "-zone", "example.com", "-name", "www.example.net."}, code: 1, stderr: []string{"not in zone"}},
+		{name: "records list zone file", args: []string{"records", "list", "-zone", "example.com", "-name", "www.example.com", "-output", "zone"}, stdout: []string{"$ORIGIN example.com.", "www\t3600\tIN\tA\t192.0.2.1"}},
+		{name: "records add", args: []string{"records", "add", "-zone", "example.com", "-name", "api", "-type", "A", "-data", "192.0.2.5"}, stdout: []string{"api", "192.0.2.5"}, requests: []string{"POST /domains/1/dns"}},
+		{name: "records add data", args: []string{"records", "add", "-zone", "example.com", "-name", "api"}, code: 1, stderr: []string{"-name, -type and -data are required"}},
+		{name: "records set", args: []string{"records", "set", "-zone", "example.com", "-name", "www", "-type", "A", "-data", "192.0.2.9"}, stdout: []string{"192.0.2.9"}, requests: []string{"PUT /domains/1/dns/10", "DELETE /domains/1/dns/11"}},
+		{name: "records set keeps data", args: []string{"records", "set", "-zone", "example.com", "-name", "www", "-type", "A", "-data", "192.0.2.2", "-ttl", "1h"}, requests: []string{"PUT /domains/1/dns/11", "DELETE /domains/1/dns/10"}},
+		{name: "records set new", args: []string{"records", "set", "-zone", "example.com", "-name", "api", "-type", "A", "-data", "192.0.2.5"}, requests: []string{"POST /domains/1/dns"}},
+		{name: "records delete", args: []string{"records", "delete", "-zone", "example.com", "-name", "txt"}, stdout: []string{"hello"}, requests: []string{"DELETE /domains/1/dns/12"}},
+		{name: "records delete name", args: []string{"records", "delete", "-zone", "example.com"}, code: 1, stderr: []string{"-name is required"}},
+		{name: "records delete none", args: []string{"records", "delete", "-zone", "example.com", "-name", "nope"}, code: 1, stderr: []string{`no records in example.com matching name "nope"`}},
+
+		{name: "forwards", args: []string{"forwards"}, code: 2, stderr: []string{"Usage: domainnameshop forwards"}},
+		{name: "forwards list", args: []string{"forwards", "list", "-zone", "example.com", "-name", "www.example.com."}, stdout: []string{"https://example.net"}},
+		{name: "forwards list zone", args: []string{"forwards", "list"}, code: 1, stderr: []string{"-zone is required"}},
+
+		{name: "import", args: []string{"import", "-zone", "example.com"}, code: 1, stderr: []string{"-zone and -file are required"}},
+		{
+			name:     "import dry run",
+			args:     []string{"import", "-zone", "example.com", "-file", "{dir}/example.com.zone", "-dry-run"},
+			files:    map[string]string{"example.com.zone": "$ORIGIN example.com.\n@ 3600 IN SOA ns1.hyp.net. hostmaster.example.com. 1 2 3 4 5\nnew 3600 IN A 192.0.2.7\n"},
+			stdout:   []string{"+ new\t1h0m0s\tA\t192.0.2.7"},
+			stderr:   []string{"warning:"},
+			requests: []string{},
+		},
+
+		{name: "snapshot", args: []string{"snapshot", "-zone", "example.com"}, stdout: []string{`"zone": "example.com"`, `"id": 11`}},
+		{name: "snapshot yaml", args: []string{"snapshot", "-zone", "example.com", "-output", "yaml"}, stdout: []string{"zone: example.com", "url: https://example.net"}},
+		{name: "snapshot zone", args: []string{"snapshot"}, code: 1, stderr: []string{"-zone is required"}},
+		{name: "restore", args: []string{"restore", "-zone", "example.com"}, code: 1, stderr: []string{"either -file or -zone and -dir are required"}},
+		{
+			name:     "restore file",
+			args:     []string{"restore", "-file", "{dir}/snapshot.json"},
+			files:    map[string]string{"snapshot.json": snapshot},
+			stdout:   []string{"- www\t1h0m0s\tA\t192.0.2.2"},
+			requests: []string{"DELETE /domains/1/dns/11"},
+		},
+		{name: "diff", args: []string{"diff"}, code: 1, stderr: []string{"either -from or -zone and -dir are required"}},
+		{
+			name:   "diff live",
+			args:   []string{"diff", "-from", "{dir}/snapshot.json", "-exit-code"},
+			files:  map[string]string{"snapshot.json": snapshot},
+			code:   3,
+			stdout: []string{"192.0.2.2"},
+		},
+
+		{name: "audit", args: []string{"audit"}, code: 1, stderr: []string{"-log or LIBDNS_DOMAINNAMESHOP_AUDIT_LOG is required"}},
+		{
+			name: "audit log",
+			args: []string{"audit", "-log", "{dir}/audit.log", "-name", "www.example.com."},
+			files: map[string]string{"audit.log": `{"time":"2026-10-18T12:00:00Z","zone":"example.com","operation":"update","record_id":10,"actor":"alice",` +
+				`"before":{"name":"www","type":"A","ttl":3600,"data":"192.0.2.1"},"after":{"name":"www","type":"A","ttl":3600,"data":"192.0.2.3"},"outcome":"ok"}` + "\n"},
+			stdout: []string{"alice", "www A 192.0.2.1", "www A 192.0.2.3"},
+		},
+
+		{name: "sweep", args: []string{"sweep"}, code: 1, stderr: []string{"-ledger or LIBDNS_DOMAINNAMESHOP_CHALLENGE_LEDGER is required"}},
+		{name: "sweep dry run", args: []string{"sweep", "-ledger", "{dir}/ledger.json", "-dry-run"}, stdout: []string{"_acme-challenge", "unknown"}, requests: []string{}},
+
+		{name: "expiry", args: []string{"expiry", "-days", "5000"}, stdout: []string{"example.com", "2030-01-01"}},
+		{name: "expiry exit code", args: []string{"expiry", "-days", "5000", "-exit-code", "-output", "none"}, code: 3},
+		{name: "expiry output", args: []string{"expiry", "-output", "xml"}, code: 1, stderr: []string{`unsupported output format "xml"`}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			api, server := newFakeAPI(t)
+			dir := t.TempDir()
+			for name, content := range c.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			args := []string{"-base-url", server.URL}
+			if !c.noCredentials {
+				args = append(args, "-token", "token", "-secret", "secret")
+			}
+			for _, arg := range c.args {
+				args = append(args, strings.ReplaceAll(arg, "{dir}", dir))
+			}
+
+			var stdout, stderr bytes.Buffer
+			if code := run(context.Background(), args, &stdout, &stderr); code != c.code {
+				t.Fatalf("exit status %d != %d\nstdout: %s\nstderr: %s", code, c.code, stdout.String(), stderr.String())
+			}
+			for _, s := range c.stdout {
+				if !strings.Contains(stdout.String(), s) {
+					t.Fatalf("stdout doesn't contain %q => %s", s, stdout.String())
+				}
+			}
+			for _, s := range c.stderr {
+				if !strings.Contains(stderr.String(), s) {
+					t.Fatalf("stderr doesn't contain %q => %s", s, stderr.String())
+				}
+			}
+			if len(c.stderr) == 0 && c.code == 0 && stderr.Len() > 0 {
+				t.Fatalf("unexpected stderr => %s", stderr.String())
+			}
+			if c.requests != nil && strings.Join(api.requests, ", ") != strings.Join(c.requests, ", ") {
+				t.Fatalf("requests != %v => %v", c.requests, api.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/libdns/domainnameshop"
+	"github.com/libdns/libdns"
+)
+
+const outputZone = "zone"
+
+var recordCommands = map[string]func(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error{
+	"list":   runRecordsList,
+	"add":    runRecordsAdd,
+	"set":    runRecordsSet,
+	"delete": runRecordsDelete,
+}
+
+func runRecords(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 || recordCommands[args[0]] == nil {
+		fmt.Fprintf(stderr, "Usage: domainnameshop records <list|add|set|delete> -zone <zone> [flags]\n")
+		return flag.ErrHelp
+	}
+	return recordCommands[args[0]](ctx, p, args[1:], stdout, stderr)
+}
+
+// recordFlags are the flags shared by the records subcommands.
+type recordFlags struct {
+	zone  string
+	name  string
+	typ   string
+	data  string
+	ttl   time.Duration
+	flags *flag.FlagSet
+}
+
+func newRecordFlags(name string, dataUsage string, stderr io.Writer) *recordFlags {
+	f := &recordFlags{flags: flag.NewFlagSet("records "+name, flag.ContinueOnError)}
+	f.flags.SetOutput(stderr)
+	f.flags.StringVar(&f.zone, "zone", "", "the `zone` to manage")
+	f.flags.StringVar(&f.name, "name", "", "record `name`, relative to the zone or fully qualified")
+	f.flags.StringVar(&f.typ, "type", "", "record `type`, like A, TXT or MX")
+	if dataUsage != "" {
+		f.flags.StringVar(&f.data, "data", "", dataUsage)
+		f.flags.DurationVar(&f.ttl, "ttl", 0, "record `ttl`, a whole number of minutes (default 2m)")
+	}
+	return f
+}
+
+func (f *recordFlags) parse(args []string) error {
+	if err := f.flags.Parse(args); err != nil {
+		return err
+	}
+	if f.zone == "" {
+		return errors.New("-zone is required")
+	}
+	f.typ = strings.ToUpper(f.typ)
+	if f.name != "" {
+		name, err := domainnameshop.RelativeName(f.name, f.zone)
+		if err != nil {
+			return err
+		}
+		f.name = name
+	}
+	return nil
+}
+
+// record builds the libdns record described by the flags, data uses the libdns.RR format, e.g. "10 mail.example.com" for MX.
+func (f *recordFlags) record() (libdns.Record, error) {
+	if f.name == "" || f.typ == "" || f.data == "" {
+		return nil, errors.New("-name, -type and -data are required")
+	}
+	return libdns.RR{
+		Name: f.name,
+		TTL:  f.ttl,
+		Type: f.typ,
+		Data: f.data,
+	}.Parse()
+}
+
+// matches reports whether rec matches the -name, -type and -data filters that are set.
+func (f *recordFlags) matches(rec libdns.Record) bool {
+	rr := rec.RR()
+	return (f.name == "" || rr.Name == f.name) &&
+		(f.typ == "" || rr.Type == f.typ) &&
+		(f.data == "" || rr.Data == f.data)
+}
+
+func (f *recordFlags) find(ctx context.Context, p *domainnameshop.Provider) ([]libdns.Record, error) {
+	records, err := p.GetRecords(ctx, f.zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []libdns.Record
+	for _, rec := range records {
+		if f.matches(rec) {
+			matched = append(matched, rec)
+		}
+	}
+	return matched, nil
+}
+
+func runRecordsList(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	f := newRecordFlags("list", "", stderr)
+	output := f.flags.String("output", outputTable, "output `format`: table, json or zone")
+	if err := f.parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON, outputZone); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	records, err := f.find(ctx, p)
+	if err != nil {
+		return err
+	}
+	return writeRecords(stdout, *output, f.zone, records)
+}
+
+func runRecordsAdd(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	f := newRecordFlags("add", "record `data` in zone file format, e.g. \"10 mail.example.com.\" for MX", stderr)
+	output := f.flags.String("output", outputTable, "output `format`: table, json or zone")
+	if err := f.parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON, outputZone); err != nil {
+		return err
+	}
+	rec, err := f.record()
+	if err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	added, err := p.AppendRecords(ctx, f.zone, []libdns.Record{rec})
+	if err != nil {
+		return err
+	}
+	return writeRecords(stdout, *output, f.zone, added)
+}
+
+func runRecordsSet(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	f := newRecordFlags("set", "record `data` in zone file format, e.g. \"10 mail.example.com.\" for MX", stderr)
+	output := f.flags.String("output", outputTable, "output `format`: table, json or zone")
+	if err := f.parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON, outputZone); err != nil {
+		return err
+	}
+	rec, err := f.record()
+	if err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	existing, err := p.GetRecords(ctx, f.zone)
+	if err != nil {
+		return err
+	}
+	set, err := p.SetRecords(ctx, f.zone, []libdns.Record{rec})
+	if err != nil {
+		return err
+	}
+
+	// The other records with the name and type are replaced by the one set. Those without
+	// RecordInfo, like TLSA, are deleted by data, which only the record set has now.
+	kept, hasInfo := domainnameshop.RecordInfoOf(set[0])
+	var replaced []libdns.Record
+	for _, r := range existing {
+		rr := r.RR()
+		if rr.Name != f.name || rr.Type != f.typ {
+			continue
+		}
+		if info, ok := domainnameshop.RecordInfoOf(r); ok && hasInfo && info == kept {
+			continue
+		}
+		if !hasInfo && rr.Data == set[0].RR().Data {
+			continue
+		}
+		replaced = append(replaced, r)
+	}
+	if len(replaced) > 0 {
+		if _, err := p.DeleteRecords(ctx, f.zone, replaced); err != nil {
+			return err
+		}
+	}
+	return writeRecords(stdout, *output, f.zone, set)
+}
+
+func runRecordsDelete(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	f := newRecordFlags("delete", "", stderr)
+	f.flags.StringVar(&f.data, "data", "", "only delete records with this `data`")
+	output := f.flags.String("output", outputTable, "output `format`: table, json or zone")
+	if err := f.parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON, outputZone); err != nil {
+		return err
+	}
+	if f.name == "" {
+		return errors.New("-name is required")
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	records, err := f.find(ctx, p)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no records in %s matching name %q", f.zone, f.name)
+	}
+
+	deleted, err := p.DeleteRecords(ctx, f.zone, records)
+	if err != nil {
+		return err
+	}
+	return writeRecords(stdout, *output, f.zone, deleted)
+}
+
+// jsonRecord is the JSON output of a record.
+type jsonRecord struct {
+	Name string `json:"name"`
+	TTL  int    `json:"ttl"`
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+func writeRecords(w io.Writer, format string, zone string, records []libdns.Record) error {
+	switch format {
+	case outputJSON:
+		out := make([]jsonRecord, 0, len(records))
+		for _, rec := range records {
+			rr := rec.RR()
+			out = append(out, jsonRecord{Name: rr.Name, TTL: int(rr.TTL.Seconds()), Type: rr.Type, Data: rr.Data})
+		}
+		return writeJSON(w, out)
+
+	case outputZone:
+		return domainnameshop.WriteZoneFile(w, zone, records)
+
+	default:
+		rows := make([][]string, 0, len(records))
+		for _, rec := range records {
+			rr := rec.RR()
+			rows = append(rows, []string{rr.Name, rr.TTL.String(), rr.Type, rr.Data})
+		}
+		return writeTable(w, []string{"NAME", "TTL", "TYPE", "DATA"}, rows)
+	}
+}
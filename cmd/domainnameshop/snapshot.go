@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/libdns/domainnameshop"
+)
+
+func runSnapshot(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	zone := flags.String("zone", "", "the `zone` to snapshot")
+	dir := flags.String("dir", "", "store the snapshot in this backup `directory` instead of printing it")
+	keep := flags.Int("keep", 0, "number of snapshots to keep in -dir, 0 keeps all")
+	maxAge := flags.Duration("max-age", 0, "remove snapshots in -dir older than this `duration`, 0 keeps all")
+	output := flags.String("output", outputJSON, "output `format` without -dir: json or yaml")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *zone == "" {
+		return errors.New("-zone is required")
+	}
+	if err := checkOutputFormat(*output, outputJSON, outputYAML); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	snapshot, err := p.Snapshot(ctx, *zone)
+	if err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		if *output == outputYAML {
+			return writeYAML(stdout, snapshot)
+		}
+		return writeJSON(stdout, snapshot)
+	}
+	store := &domainnameshop.BackupStore{Dir: *dir, MaxCount: *keep, MaxAge: *maxAge}
+	path, err := store.Save(snapshot)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, path)
+	return nil
+}
+
+func runRestore(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("file", "", "snapshot `file` to restore, read as YAML if named .yaml or .yml")
+	zone := flags.String("zone", "", "restore the latest snapshot of this `zone` from -dir")
+	dir := flags.String("dir", "", "backup `directory` to restore from")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*file == "") == (*zone == "" || *dir == "") {
+		return errors.New("either -file or -zone and -dir are required")
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	var snapshot *domainnameshop.Snapshot
+	var err error
+	if *file != "" {
+		snapshot, err = domainnameshop.LoadSnapshot(*file)
+	} else {
+		snapshot, err = (&domainnameshop.BackupStore{Dir: *dir}).Latest(*zone)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := p.Restore(ctx, snapshot)
+	writeChanges(stdout, "+", result.Created)
+	writeChanges(stdout, "~", result.Updated)
+	writeChanges(stdout, "-", result.Deleted)
+	for _, f := range result.CreatedForwards {
+		fmt.Fprintf(stdout, "+ forward %s\t%s\n", f.Host, f.URL)
+	}
+	for _, f := range result.UpdatedForwards {
+		fmt.Fprintf(stdout, "~ forward %s\t%s\n", f.Host, f.URL)
+	}
+	for _, f := range result.DeletedForwards {
+		fmt.Fprintf(stdout, "- forward %s\t%s\n", f.Host, f.URL)
+	}
+	return err
+}
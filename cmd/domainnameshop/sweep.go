@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/libdns/domainnameshop"
+)
+
+func runSweep(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	ledger := flags.String("ledger", os.Getenv("LIBDNS_DOMAINNAMESHOP_CHALLENGE_LEDGER"), "challenge ledger `file` written by the provider")
+	zone := flags.String("zone", "", "sweep only this `zone` instead of all zones")
+	olderThan := flags.Duration("older-than", 24*time.Hour, "delete challenge records created longer than this `duration` ago")
+	dryRun := flags.Bool("dry-run", false, "report what would be deleted without deleting it")
+	output := flags.String("output", outputTable, "output `format`: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *ledger == "" {
+		return errors.New("-ledger or LIBDNS_DOMAINNAMESHOP_CHALLENGE_LEDGER is required")
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	p.ChallengeLedger = &domainnameshop.ChallengeLedger{Path: *ledger}
+	opts := domainnameshop.SweepOptions{OlderThan: *olderThan, DryRun: *dryRun}
+	if *zone != "" {
+		opts.Zones = []string{*zone}
+	}
+
+	// Report what was found even if a deletion failed
+	swept, sweepErr := p.SweepChallenges(ctx, opts)
+	var err error
+	if *output == outputJSON {
+		if swept == nil {
+			swept = []domainnameshop.SweptRecord{}
+		}
+		err = writeJSON(stdout, swept)
+	} else {
+		rows := make([][]string, 0, len(swept))
+		for _, s := range swept {
+			created := "-"
+			if !s.CreatedAt.IsZero() {
+				created = s.CreatedAt.Local().Format(time.DateTime)
+			}
+			rows = append(rows, []string{s.Zone, s.Name, strconv.Itoa(s.RecordID), created, string(s.Action), s.Value})
+		}
+		err = writeTable(stdout, []string{"ZONE", "NAME", "ID", "CREATED", "ACTION", "VALUE"}, rows)
+	}
+	if sweepErr != nil {
+		return sweepErr
+	}
+	return err
+}
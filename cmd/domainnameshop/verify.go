@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"strconv"
+
+	"github.com/libdns/domainnameshop"
+)
+
+func runVerify(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("output", outputTable, "output `format`: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	result, err := p.Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return writeJSON(stdout, result)
+	}
+	rows := make([][]string, 0, len(result.Domains))
+	for _, d := range result.Domains {
+		webhotel := d.Services.Webhotel
+		if webhotel == "" {
+			webhotel = "none"
+		}
+		rows = append(rows, []string{
+			d.Name,
+			strconv.FormatBool(d.Services.DNS),
+			strconv.FormatBool(d.Services.Email),
+			strconv.FormatBool(d.Services.Registrar),
+			webhotel,
+		})
+	}
+	return writeTable(stdout, []string{"DOMAIN", "DNS", "EMAIL", "REGISTRAR", "WEBHOTEL"}, rows)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/domainnameshop"
+)
+
+func runZones(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("zones", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	filter := flags.String("filter", "", "only list domains whose name contains `text`")
+	output := flags.String("output", outputTable, "output `format`: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	domains, err := p.ListDomains(ctx, *filter)
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		if domains == nil {
+			domains = []domainnameshop.Domain{}
+		}
+		return writeJSON(stdout, domains)
+	}
+
+	rows := make([][]string, 0, len(domains))
+	for _, d := range domains {
+		rows = append(rows, []string{
+			strconv.Itoa(d.ID),
+			d.Name,
+			string(d.Status),
+			formatDate(d),
+			strconv.FormatBool(d.Renew),
+			strconv.FormatBool(d.Services.DNS),
+		})
+	}
+	return writeTable(stdout, []string{"ID", "DOMAIN", "STATUS", "EXPIRES", "RENEW", "DNS"}, rows)
+}
+
+func runGet(ctx context.Context, p *domainnameshop.Provider, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("output", outputTable, "output `format`: table or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: domainnameshop get [flags] <domain>\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	if err := checkOutputFormat(*output, outputTable, outputJSON); err != nil {
+		return err
+	}
+	if err := requireCredentials(p); err != nil {
+		return err
+	}
+
+	d, err := p.GetDomain(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return writeJSON(stdout, d)
+	}
+
+	registered := "-"
+	if !d.RegisteredDate.IsZero() {
+		registered = d.RegisteredDate.Format("2006-01-02")
+	}
+	return writeTable(stdout, []string{"FIELD", "VALUE"}, [][]string{
+		{"id", strconv.Itoa(d.ID)},
+		{"domain", d.Name},
+		{"status", string(d.Status)},
+		{"registrant", d.Registrant},
+		{"registered", registered},
+		{"expires", formatDate(d)},
+		{"renew", strconv.FormatBool(d.Renew)},
+		{"nameservers", strings.Join(d.Nameservers, ",")},
+		{"dns", strconv.FormatBool(d.Services.DNS)},
+		{"email", strconv.FormatBool(d.Services.Email)},
+		{"registrar", strconv.FormatBool(d.Services.Registrar)},
+		{"webhotel", d.Services.Webhotel},
+	})
+}
+
+// formatDate formats the expiry date of d, or "-" if it has none.
+func formatDate(d domainnameshop.Domain) string {
+	if d.ExpiryDate.IsZero() {
+		return "-"
+	}
+	return d.ExpiryDate.Format("2006-01-02")
+}
@@ -0,0 +1,160 @@
+package domainnameshop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials are an API token and secret.
+// https://api.domeneshop.no/docs/#section/Authentication
+type Credentials struct {
+	Token  string
+	Secret string
+}
+
+// CredentialsProvider supplies the credentials for API requests. It's asked before
+// every operation, so credentials can be rotated without recreating the Provider
+// and losing its caches. Implementations must be safe for concurrent use.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// credentials returns the token and secret from p.Credentials, or else from APIToken and APISecret.
+func (p *Provider) credentials(ctx context.Context) (string, string, error) {
+	if p.Credentials == nil {
+		return p.APIToken, p.APISecret, nil
+	}
+	c, err := p.Credentials.Credentials(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("getting credentials: %w", err)
+	}
+	return c.Token, c.Secret, nil
+}
+
+// Environment variables read by EnvCredentials by default.
+const (
+	EnvToken  = "LIBDNS_DOMAINNAMESHOP_TOKEN"
+	EnvSecret = "LIBDNS_DOMAINNAMESHOP_SECRET"
+)
+
+// EnvCredentials reads the credentials from environment variables. If a variable is
+// empty, the file named by the variable with a _FILE suffix is read instead, the way
+// Docker and Kubernetes secrets are usually passed. Files are read every time, so
+// they can be replaced to rotate the credentials.
+type EnvCredentials struct {
+	// TokenVar is the variable with the token, EnvToken if empty.
+	TokenVar string
+	// SecretVar is the variable with the secret, EnvSecret if empty.
+	SecretVar string
+}
+
+func (e EnvCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	tokenVar, secretVar := e.TokenVar, e.SecretVar
+	if tokenVar == "" {
+		tokenVar = EnvToken
+	}
+	if secretVar == "" {
+		secretVar = EnvSecret
+	}
+
+	token, err := readEnv(tokenVar)
+	if err != nil {
+		return Credentials{}, err
+	}
+	secret, err := readEnv(secretVar)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return Credentials{Token: token, Secret: secret}, nil
+}
+
+func readEnv(name string) (string, error) {
+	if value := os.Getenv(name); value != "" {
+		return value, nil
+	}
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		return readSecretFile(path)
+	}
+	return "", fmt.Errorf("%s or %s_FILE must be set", name, name)
+}
+
+// readSecretFile reads a file holding a single secret, ignoring surrounding white space.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return value, nil
+}
+
+// FileCredentials reads the token and secret from two files, like a mounted Kubernetes
+// secret. The files are read again when their modification time changes, checked at most
+// every CheckInterval, so rotated credentials are picked up without a restart. While the
+// files can't be read, the last credentials read are used.
+type FileCredentials struct {
+	TokenFile  string
+	SecretFile string
+	// CheckInterval is the minimum time between checks of the files, 0 checks every time.
+	CheckInterval time.Duration
+
+	mu          sync.Mutex
+	credentials Credentials
+	modTimes    [2]time.Time
+	checked     time.Time
+}
+
+func (f *FileCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	loaded := f.credentials != Credentials{}
+	if loaded && now.Sub(f.checked) < f.CheckInterval {
+		return f.credentials, nil
+	}
+	f.checked = now
+
+	var modTimes [2]time.Time
+	for i, path := range []string{f.TokenFile, f.SecretFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			if loaded {
+				return f.credentials, nil
+			}
+			return Credentials{}, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	if loaded && modTimes == f.modTimes {
+		return f.credentials, nil
+	}
+
+	token, err := readSecretFile(f.TokenFile)
+	if err == nil {
+		var secret string
+		if secret, err = readSecretFile(f.SecretFile); err == nil {
+			f.credentials = Credentials{Token: token, Secret: secret}
+			f.modTimes = modTimes
+			return f.credentials, nil
+		}
+	}
+	if loaded {
+		// The files may be halfway through being replaced
+		return f.credentials, nil
+	}
+	return Credentials{}, fmt.Errorf("reading credentials: %w", err)
+}
+
+// Interface guards
+var (
+	_ CredentialsProvider = EnvCredentials{}
+	_ CredentialsProvider = (*FileCredentials)(nil)
+)
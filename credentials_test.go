@@ -0,0 +1,85 @@
+package domainnameshop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_EnvCredentials(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_DOMAINNAMESHOP_TOKEN", "from-env")
+	t.Setenv("TEST_DOMAINNAMESHOP_SECRET", "")
+	t.Setenv("TEST_DOMAINNAMESHOP_SECRET_FILE", secretFile)
+
+	p := &Provider{
+		APIToken:    "ignored",
+		Credentials: EnvCredentials{TokenVar: "TEST_DOMAINNAMESHOP_TOKEN", SecretVar: "TEST_DOMAINNAMESHOP_SECRET"},
+	}
+	token, secret, err := p.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("credentials failed => %v", err)
+	}
+	if token != "from-env" || secret != "from-file" {
+		t.Fatalf("unexpected credentials => %q, %q", token, secret)
+	}
+
+	t.Setenv("TEST_DOMAINNAMESHOP_SECRET_FILE", "")
+	if _, _, err := p.credentials(context.Background()); err == nil {
+		t.Fatal("expected error without secret")
+	}
+}
+
+func Test_FileCredentialsRotation(t *testing.T) {
+	dir := t.TempDir()
+	creds := &FileCredentials{TokenFile: filepath.Join(dir, "token"), SecretFile: filepath.Join(dir, "secret")}
+	write := func(token, secret string, modTime time.Time) {
+		t.Helper()
+		for path, value := range map[string]string{creds.TokenFile: token, creds.SecretFile: secret} {
+			if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := creds.Credentials(context.Background()); err == nil {
+		t.Fatal("expected error without files")
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write("token1", "secret1", start)
+	c, err := creds.Credentials(context.Background())
+	if err != nil || c != (Credentials{"token1", "secret1"}) {
+		t.Fatalf("unexpected credentials => %+v, %v", c, err)
+	}
+
+	write("token2", "secret2", start.Add(time.Minute))
+	c, err = creds.Credentials(context.Background())
+	if err != nil || c != (Credentials{"token2", "secret2"}) {
+		t.Fatalf("rotated credentials not picked up => %+v, %v", c, err)
+	}
+
+	// While the files are being replaced the last credentials are used
+	os.Remove(creds.SecretFile)
+	c, err = creds.Credentials(context.Background())
+	if err != nil || c != (Credentials{"token2", "secret2"}) {
+		t.Fatalf("unexpected credentials while rotating => %+v, %v", c, err)
+	}
+
+	// Within CheckInterval the files aren't looked at
+	creds.CheckInterval = time.Hour
+	write("token3", "secret3", start.Add(2*time.Minute))
+	c, _ = creds.Credentials(context.Background())
+	if c != (Credentials{"token2", "secret2"}) {
+		t.Fatalf("files checked within CheckInterval => %+v", c)
+	}
+}
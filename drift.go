@@ -0,0 +1,362 @@
+package domainnameshop
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ChangeKind is the kind of a RecordChange.
+type ChangeKind string
+
+const (
+	RecordAdded    ChangeKind = "added"
+	RecordRemoved  ChangeKind = "removed"
+	RecordModified ChangeKind = "modified"
+)
+
+// RecordChange is a record that differs between two states of a zone.
+// Before is nil for added records and After is nil for removed records.
+type RecordChange struct {
+	Kind   ChangeKind      `json:"kind"`
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Before *SnapshotRecord `json:"before,omitempty"`
+	After  *SnapshotRecord `json:"after,omitempty"`
+	Fields []FieldChange   `json:"fields,omitempty"`
+}
+
+// FieldChange is a field of a modified record.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ZoneDiff lists the record changes between two states of a zone.
+type ZoneDiff struct {
+	Zone    string         `json:"zone"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Changes []RecordChange `json:"changes"`
+}
+
+// Empty reports whether there are no changes.
+func (d *ZoneDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// DiffSnapshots compares two snapshots of a zone. Records are matched on name and type,
+// then on Domeneshop record ID or identical content; records with the same name and type
+// that can't be matched that way are reported as modified.
+func DiffSnapshots(from *Snapshot, to *Snapshot) *ZoneDiff {
+	diff := &ZoneDiff{
+		Zone:    to.Zone,
+		From:    from.TakenAt,
+		To:      to.TakenAt,
+		Changes: []RecordChange{},
+	}
+
+	type nameType struct{ name, rrType string }
+	group := func(records []SnapshotRecord) map[nameType][]SnapshotRecord {
+		groups := make(map[nameType][]SnapshotRecord)
+		for _, r := range records {
+			key := nameType{strings.ToLower(r.Host), r.Type}
+			groups[key] = append(groups[key], r)
+		}
+		return groups
+	}
+	before, after := group(from.Records), group(to.Records)
+
+	keys := make(map[nameType]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	sorted := make([]nameType, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].name != sorted[j].name {
+			return sorted[i].name < sorted[j].name
+		}
+		return sorted[i].rrType < sorted[j].rrType
+	})
+
+	for _, k := range sorted {
+		diff.Changes = append(diff.Changes, diffGroup(before[k], after[k])...)
+	}
+
+	return diff
+}
+
+// diffGroup compares records with the same name and type.
+func diffGroup(before []SnapshotRecord, after []SnapshotRecord) []RecordChange {
+	var changes []RecordChange
+	before = append([]SnapshotRecord(nil), before...)
+	after = append([]SnapshotRecord(nil), after...)
+
+	take := func(match func(b, a SnapshotRecord) bool) {
+		for i := 0; i < len(before); i++ {
+			for j := 0; j < len(after); j++ {
+				if !match(before[i], after[j]) {
+					continue
+				}
+				if fields := diffFields(before[i], after[j]); len(fields) > 0 {
+					b, a := before[i], after[j]
+					changes = append(changes, RecordChange{Kind: RecordModified, Name: a.Host, Type: a.Type, Before: &b, After: &a, Fields: fields})
+				}
+				before = append(before[:i], before[i+1:]...)
+				after = append(after[:j], after[j+1:]...)
+				i--
+				break
+			}
+		}
+	}
+	take(func(b, a SnapshotRecord) bool { return b.ID != 0 && b.ID == a.ID })
+	take(func(b, a SnapshotRecord) bool { return recordKey(b.dsDNSRecord()) == recordKey(a.dsDNSRecord()) })
+	take(func(b, a SnapshotRecord) bool { return true })
+
+	for i := range before {
+		changes = append(changes, RecordChange{Kind: RecordRemoved, Name: before[i].Host, Type: before[i].Type, Before: &before[i]})
+	}
+	for i := range after {
+		changes = append(changes, RecordChange{Kind: RecordAdded, Name: after[i].Host, Type: after[i].Type, After: &after[i]})
+	}
+	return changes
+}
+
+func diffFields(before SnapshotRecord, after SnapshotRecord) []FieldChange {
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"ttl", fmt.Sprint(before.TTL), fmt.Sprint(after.TTL)},
+		{"data", before.Data, after.Data},
+		{"priority", before.Priority, after.Priority},
+		{"weight", before.Weight, after.Weight},
+		{"port", before.Port, after.Port},
+		{"flags", before.Flags, after.Flags},
+		{"tag", before.Tag, after.Tag},
+		{"usage", before.Usage, after.Usage},
+		{"selector", before.Selector, after.Selector},
+		{"dtype", before.DType, after.DType},
+		{"alg", before.Alg, after.Alg},
+		{"digest", before.Digest, after.Digest},
+	}
+
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, FieldChange{Field: f.name, Before: f.before, After: f.after})
+		}
+	}
+	return changes
+}
+
+// DiffLive compares a snapshot with the live records of its zone.
+func (p *Provider) DiffLive(ctx context.Context, snapshot *Snapshot) (_ *ZoneDiff, err error) {
+	ctx, span := p.startSpan(ctx, "DiffLive", Attribute{AttrZone, snapshot.Zone})
+	defer func() { endSpan(span, err) }()
+	live, err := p.liveSnapshot(ctx, snapshot.Zone)
+	if err != nil {
+		return nil, err
+	}
+	return DiffSnapshots(snapshot, live), nil
+}
+
+// liveSnapshot is a snapshot of the records of the zone, without HTTP forwards.
+func (p *Provider) liveSnapshot(ctx context.Context, zone string) (*Snapshot, error) {
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := p.getAllDomainRecords(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Version: SnapshotVersion,
+		Zone:    removeFQDNTrailingDot(zone),
+		TakenAt: time.Now().UTC(),
+		Records: make([]SnapshotRecord, 0, len(records)),
+	}
+	for _, r := range records {
+		snapshot.Records = append(snapshot.Records, snapshotRecord(r))
+	}
+	return snapshot, nil
+}
+
+// WriteText writes the changes as one line per record, followed by the changed fields.
+func (d *ZoneDiff) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+	if d.Empty() {
+		fmt.Fprintf(&buf, "%s: no changes\n", d.Zone)
+	}
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case RecordAdded:
+			fmt.Fprintf(&buf, "+ %s %s %s (ttl %d)\n", c.Name, c.Type, c.After.Data, c.After.TTL)
+		case RecordRemoved:
+			fmt.Fprintf(&buf, "- %s %s %s (ttl %d)\n", c.Name, c.Type, c.Before.Data, c.Before.TTL)
+		case RecordModified:
+			fmt.Fprintf(&buf, "~ %s %s\n", c.Name, c.Type)
+			for _, f := range c.Fields {
+				fmt.Fprintf(&buf, "    %s: %q -> %q\n", f.Field, f.Before, f.After)
+			}
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteUnifiedDiff writes the difference between the zone files of two snapshots as a unified diff.
+func WriteUnifiedDiff(w io.Writer, from *Snapshot, to *Snapshot) error {
+	render := func(s *Snapshot) ([]string, error) {
+		records := make([]libdns.Record, 0, len(s.Records))
+		for _, r := range s.Records {
+			rec, err := r.dsDNSRecord().libdnsRecord()
+			if err != nil {
+				return nil, fmt.Errorf("parsing Domainnameshop DNS record %+v: %v", r, err)
+			}
+			records = append(records, rec)
+		}
+		var buf bytes.Buffer
+		if err := WriteZoneFile(&buf, s.Zone, records); err != nil {
+			return nil, err
+		}
+		return strings.SplitAfter(buf.String(), "\n"), nil
+	}
+
+	a, err := render(from)
+	if err != nil {
+		return err
+	}
+	b, err := render(to)
+	if err != nil {
+		return err
+	}
+
+	label := func(s *Snapshot) string {
+		return fmt.Sprintf("%s\t%s", s.Zone, s.TakenAt.UTC().Format(time.RFC3339))
+	}
+	_, err = io.WriteString(w, unifiedDiff(label(from), label(to), a, b))
+	return err
+}
+
+// unifiedDiff returns a unified diff with 3 lines of context of the lines a and b,
+// which include their line endings. It's empty if they are equal.
+func unifiedDiff(aName string, bName string, a []string, b []string) string {
+	if len(a) > 0 && a[len(a)-1] == "" {
+		a = a[:len(a)-1]
+	}
+	if len(b) > 0 && b[len(b)-1] == "" {
+		b = b[:len(b)-1]
+	}
+
+	// Longest common subsequence, lcs[i][j] is the length for a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte // ' ', '-' or '+'
+		line string
+		ai   int // Line in a before this edit
+		bi   int // Line in b before this edit
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	const contextLines = 3
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk until there are more than 2*contextLines unchanged lines
+		end := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				end = k + 1
+			} else if k-end >= 2*contextLines {
+				break
+			}
+		}
+		from := max(start-contextLines, 0)
+		to := min(end+contextLines, len(edits))
+
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(edits[from].ai, aCount), hunkRange(edits[from].bi, bCount))
+		for _, e := range edits[from:to] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+
+	return out.String()
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
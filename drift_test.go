@@ -0,0 +1,93 @@
+package domainnameshop
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_DiffSnapshots(t *testing.T) {
+	from := &Snapshot{
+		Zone:    "example.com",
+		TakenAt: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+		Records: []SnapshotRecord{
+			{ID: 1, Host: "www", Type: "A", TTL: 3600, Data: "192.0.2.1"},
+			{ID: 2, Host: "www", Type: "A", TTL: 3600, Data: "192.0.2.2"},
+			{ID: 3, Host: "@", Type: "MX", TTL: 3600, Data: "mail.example.com", Priority: "10"},
+			{ID: 4, Host: "old", Type: "TXT", TTL: 3600, Data: "old"},
+		},
+	}
+	to := &Snapshot{
+		Zone:    "example.com",
+		TakenAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Records: []SnapshotRecord{
+			{ID: 2, Host: "www", Type: "A", TTL: 3600, Data: "192.0.2.2"},
+			{ID: 5, Host: "www", Type: "A", TTL: 3600, Data: "192.0.2.3"},
+			{ID: 3, Host: "@", Type: "MX", TTL: 300, Data: "mail.example.com", Priority: "20"},
+			{ID: 6, Host: "new", Type: "TXT", TTL: 3600, Data: "new"},
+		},
+	}
+
+	diff := DiffSnapshots(from, to)
+	expected := []struct {
+		kind ChangeKind
+		name string
+	}{
+		{RecordModified, "@"},
+		{RecordAdded, "new"},
+		{RecordRemoved, "old"},
+		{RecordModified, "www"},
+	}
+	if len(diff.Changes) != len(expected) {
+		t.Fatalf("len(diff.Changes) != %d => %+v", len(expected), diff.Changes)
+	}
+	for k, exp := range expected {
+		if c := diff.Changes[k]; c.Kind != exp.kind || c.Name != exp.name {
+			t.Fatalf("diff.Changes[%d] != %s %s => %s %s", k, exp.kind, exp.name, c.Kind, c.Name)
+		}
+	}
+	mx := diff.Changes[0].Fields
+	if len(mx) != 2 || mx[0].Field != "ttl" || mx[1].Field != "priority" || mx[1].After != "20" {
+		t.Fatalf("unexpected MX field changes => %+v", mx)
+	}
+
+	var text bytes.Buffer
+	if err := diff.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "+ new TXT new (ttl 3600)") || !strings.Contains(text.String(), `data: "192.0.2.1" -> "192.0.2.3"`) {
+		t.Fatalf("unexpected text output:\n%s", text.String())
+	}
+
+	if !DiffSnapshots(to, to).Empty() {
+		t.Fatal("expected no changes between identical snapshots")
+	}
+}
+
+func Test_WriteUnifiedDiff(t *testing.T) {
+	from := &Snapshot{Zone: "example.com", Records: []SnapshotRecord{
+		{Host: "a", Type: "A", TTL: 120, Data: "192.0.2.1"},
+		{Host: "b", Type: "A", TTL: 120, Data: "192.0.2.2"},
+	}}
+	to := &Snapshot{Zone: "example.com", Records: []SnapshotRecord{
+		{Host: "a", Type: "A", TTL: 120, Data: "192.0.2.1"},
+		{Host: "b", Type: "A", TTL: 120, Data: "192.0.2.3"},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteUnifiedDiff(&buf, from, to); err != nil {
+		t.Fatal(err)
+	}
+	expected := "--- example.com\t0001-01-01T00:00:00Z\n" +
+		"+++ example.com\t0001-01-01T00:00:00Z\n" +
+		"@@ -1,4 +1,4 @@\n" +
+		" $ORIGIN example.com.\n" +
+		" $TTL 120\n" +
+		" a\t120\tIN\tA\t192.0.2.1\n" +
+		"-b\t120\tIN\tA\t192.0.2.2\n" +
+		"+b\t120\tIN\tA\t192.0.2.3\n"
+	if buf.String() != expected {
+		t.Fatalf("unified diff mismatch\ngot:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
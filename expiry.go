@@ -0,0 +1,71 @@
+package domainnameshop
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ExpiryIssue is a reason a domain needs attention.
+type ExpiryIssue string
+
+const (
+	// ExpiryIssueExpiring is reported for domains expiring within the checked period, or already expired.
+	ExpiryIssueExpiring ExpiryIssue = "expiring"
+	// ExpiryIssueRenewDisabled is reported for domains that will not be renewed automatically.
+	ExpiryIssueRenewDisabled ExpiryIssue = "renew-disabled"
+	// ExpiryIssueInactive is reported for domains whose status isn't active.
+	ExpiryIssueInactive ExpiryIssue = "inactive"
+)
+
+// ExpiryFinding is a domain with one or more issues found by CheckExpiry.
+type ExpiryFinding struct {
+	Domain   Domain        `json:"domain"`
+	DaysLeft int           `json:"days_left"`
+	Issues   []ExpiryIssue `json:"issues"`
+}
+
+// CheckExpiry walks all domains in the account and returns those expiring within
+// the given number of days, those with renew disabled and those that aren't active.
+// Findings are sorted by expiry date, soonest first.
+func (p *Provider) CheckExpiry(ctx context.Context, days int) (_ []ExpiryFinding, err error) {
+	ctx, span := p.startSpan(ctx, "CheckExpiry")
+	defer func() { endSpan(span, err) }()
+	domains, err := p.ListDomains(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return checkExpiry(domains, time.Now(), days), nil
+}
+
+func checkExpiry(domains []Domain, now time.Time, days int) []ExpiryFinding {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var findings []ExpiryFinding
+	for _, domain := range domains {
+		finding := ExpiryFinding{Domain: domain}
+		if !domain.ExpiryDate.IsZero() {
+			finding.DaysLeft = int(domain.ExpiryDate.Sub(today).Hours() / 24)
+			if finding.DaysLeft <= days {
+				finding.Issues = append(finding.Issues, ExpiryIssueExpiring)
+			}
+		}
+		if !domain.Renew {
+			finding.Issues = append(finding.Issues, ExpiryIssueRenewDisabled)
+		}
+		if domain.Status != DomainStatusActive {
+			finding.Issues = append(finding.Issues, ExpiryIssueInactive)
+		}
+
+		if len(finding.Issues) > 0 {
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Domain.ExpiryDate.Before(findings[j].Domain.ExpiryDate)
+	})
+
+	return findings
+}
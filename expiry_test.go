@@ -0,0 +1,52 @@
+package domainnameshop
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_checkExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)
+	date := func(days int) time.Time {
+		return time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+	}
+
+	domains := []Domain{
+		{Name: "fine.no", ExpiryDate: date(200), Renew: true, Status: DomainStatusActive},
+		{Name: "soon.no", ExpiryDate: date(10), Renew: true, Status: DomainStatusActive},
+		{Name: "norenew.no", ExpiryDate: date(100), Renew: false, Status: DomainStatusActive},
+		{Name: "gone.no", ExpiryDate: date(-3), Renew: false, Status: DomainStatusExpired},
+	}
+
+	findings := checkExpiry(domains, now, 30)
+	if len(findings) != 3 {
+		t.Fatalf("len(findings) != 3 => %d", len(findings))
+	}
+
+	expected := []struct {
+		name     string
+		daysLeft int
+		issues   []ExpiryIssue
+	}{
+		{"gone.no", -3, []ExpiryIssue{ExpiryIssueExpiring, ExpiryIssueRenewDisabled, ExpiryIssueInactive}},
+		{"soon.no", 10, []ExpiryIssue{ExpiryIssueExpiring}},
+		{"norenew.no", 100, []ExpiryIssue{ExpiryIssueRenewDisabled}},
+	}
+	for k, exp := range expected {
+		f := findings[k]
+		if f.Domain.Name != exp.name {
+			t.Fatalf("findings[%d].Domain.Name != %s => %s", k, exp.name, f.Domain.Name)
+		}
+		if f.DaysLeft != exp.daysLeft {
+			t.Fatalf("findings[%d].DaysLeft != %d => %d", k, exp.daysLeft, f.DaysLeft)
+		}
+		if len(f.Issues) != len(exp.issues) {
+			t.Fatalf("findings[%d].Issues != %v => %v", k, exp.issues, f.Issues)
+		}
+		for i := range exp.issues {
+			if f.Issues[i] != exp.issues[i] {
+				t.Fatalf("findings[%d].Issues != %v => %v", k, exp.issues, f.Issues)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package domainnameshop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// FindZone returns the domain in the account that fqdn belongs to, like example.co.uk for
+// _acme-challenge.api.eu.example.co.uk. When several domains match, like example.co.uk and
+// eu.example.co.uk, the longest one is returned. The zone is in Unicode form if fqdn is.
+func (p *Provider) FindZone(ctx context.Context, fqdn string) (_ string, err error) {
+	ctx, span := p.startSpan(ctx, "FindZone")
+	defer func() { endSpan(span, err) }()
+
+	name := zoneKey(fqdn)
+	if name == "" {
+		return "", fmt.Errorf("no name to find the zone of")
+	}
+
+	// Only a complete list of domains tells which matching domain is the longest
+	if zone, ok := p.findCachedZone(name); ok && p.allZonesCached() {
+		p.cacheLookup(ctx, ZoneCache, true)
+		if wantsUnicode(fqdn) {
+			zone = toUnicodeName(zone)
+		}
+		return zone, nil
+	}
+	p.cacheLookup(ctx, ZoneCache, false)
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	domains, err := p.listDomains(ctx, token, secret, "")
+	if err != nil {
+		return "", err
+	}
+	p.cacheDomains(domains, true)
+
+	if zone, ok := p.findCachedZone(name); ok {
+		if wantsUnicode(fqdn) {
+			zone = toUnicodeName(zone)
+		}
+		return zone, nil
+	}
+	return "", fmt.Errorf("no domain in the account contains %s", fqdn)
+}
+
+func (p *Provider) allZonesCached() bool {
+	p.zonesMu.Lock()
+	defer p.zonesMu.Unlock()
+	return p.zonesComplete
+}
+
+// findCachedZone looks up the zone of name, a zone key, in the zone cache, trying the
+// name itself first and then each parent name.
+func (p *Provider) findCachedZone(name string) (string, bool) {
+	p.zonesMu.Lock()
+	defer p.zonesMu.Unlock()
+	for candidate := name; ; {
+		if domain, ok := p.zones[candidate]; ok {
+			return domain.Name, true
+		}
+		_, parent, ok := strings.Cut(candidate, ".")
+		if !ok {
+			return "", false
+		}
+		candidate = parent
+	}
+}
+
+// zoneGroup is the records of one zone when records with fully qualified names are
+// passed without zone.
+type zoneGroup struct {
+	zone    string
+	records []libdns.Record
+}
+
+// groupByZone finds the zone of each record, which must have a fully qualified name,
+// and makes the names relative to the zone. Groups are in the order of their first record.
+func (p *Provider) groupByZone(ctx context.Context, records []libdns.Record) ([]*zoneGroup, error) {
+	var groups []*zoneGroup
+	byZone := make(map[string]*zoneGroup)
+	for _, rec := range records {
+		rr := rec.RR()
+		if rr.Name == "" || rr.Name == "@" {
+			return nil, fmt.Errorf("record %s %s needs a fully qualified name without zone", rr.Name, rr.Type)
+		}
+		zone, err := p.FindZone(ctx, rr.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		host, err := apiHost(rr.Name, zone)
+		if err != nil {
+			return nil, err
+		}
+		// The zone is passed on in the form of the name, so results come back in it too
+		zone = zoneKey(zone)
+		if wantsUnicode(rr.Name) {
+			host, zone = toUnicodeName(host), toUnicodeName(zone)
+		}
+		relative, err := withName(rec, host)
+		if err != nil {
+			return nil, err
+		}
+
+		g, ok := byZone[zone]
+		if !ok {
+			g = &zoneGroup{zone: zone}
+			byZone[zone] = g
+			groups = append(groups, g)
+		}
+		g.records = append(g.records, relative)
+	}
+	return groups, nil
+}
+
+// forEachZone runs fn for the records of each zone, for methods called without zone.
+// The records it returns get fully qualified names again.
+func (p *Provider) forEachZone(ctx context.Context, records []libdns.Record, fn func(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)) ([]libdns.Record, error) {
+	groups, err := p.groupByZone(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []libdns.Record
+	for _, g := range groups {
+		done, err := fn(ctx, g.zone, g.records)
+		for _, rec := range done {
+			absolute, convErr := withName(rec, absoluteName(rec.RR().Name, g.zone))
+			if convErr != nil {
+				return results, convErr
+			}
+			results = append(results, absolute)
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// withName returns rec with another name, keeping its ProviderData.
+func withName(rec libdns.Record, name string) (libdns.Record, error) {
+	rr := rec.RR()
+	rr.Name = name
+	renamed, err := rr.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return withProviderData(renamed, providerData(rec)), nil
+}
@@ -0,0 +1,74 @@
+package domainnameshop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_FindZone(t *testing.T) {
+	server := newTestAPI(t, map[string][]Domain{
+		"token": {
+			{ID: 1, Name: "example.co.uk"},
+			{ID: 2, Name: "eu.example.co.uk"},
+			{ID: 3, Name: "myexample.co.uk"},
+		},
+	}, nil)
+	p := &Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}
+
+	for name, expected := range map[string]string{
+		"_acme-challenge.api.eu.example.co.uk.": "eu.example.co.uk",
+		"www.Example.co.uk":                     "example.co.uk",
+		"example.co.uk.":                        "example.co.uk",
+		"www.myexample.co.uk":                   "myexample.co.uk",
+	} {
+		zone, err := p.FindZone(context.Background(), name)
+		if err != nil {
+			t.Fatalf("FindZone(%q) failed => %v", name, err)
+		}
+		if zone != expected {
+			t.Fatalf("FindZone(%q) != %q => %q", name, expected, zone)
+		}
+	}
+
+	if zone, err := p.FindZone(context.Background(), "www.example.org"); err == nil {
+		t.Fatalf("expected error for unknown zone => %q", zone)
+	}
+
+	// The domain filter of the API also matches myexample.co.uk
+	domain, err := (&Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}).GetDomain(context.Background(), "example.co.uk")
+	if err != nil || domain.ID != 1 {
+		t.Fatalf("GetDomain picked the wrong domain => %+v, %v", domain, err)
+	}
+}
+
+func Test_groupByZone(t *testing.T) {
+	p := &Provider{
+		zones: map[string]Domain{
+			"example.com": {ID: 1, Name: "example.com"},
+			"example.net": {ID: 2, Name: "example.net"},
+		},
+		zonesComplete: true,
+	}
+
+	groups, err := p.groupByZone(context.Background(), []libdns.Record{
+		libdns.TXT{Name: "_acme-challenge.example.net.", Text: "a"},
+		libdns.TXT{Name: "_acme-challenge.www.example.com.", Text: "b"},
+		libdns.TXT{Name: "example.net.", Text: "c"},
+	})
+	if err != nil {
+		t.Fatalf("groupByZone failed => %v", err)
+	}
+	if len(groups) != 2 || groups[0].zone != "example.net" || groups[1].zone != "example.com" {
+		t.Fatalf("unexpected groups => %+v", groups)
+	}
+	names := []string{groups[0].records[0].RR().Name, groups[0].records[1].RR().Name, groups[1].records[0].RR().Name}
+	if names[0] != "_acme-challenge" || names[1] != "@" || names[2] != "_acme-challenge.www" {
+		t.Fatalf("unexpected relative names => %v", names)
+	}
+
+	if _, err := p.groupByZone(context.Background(), []libdns.Record{libdns.TXT{Name: "@", Text: "a"}}); err == nil {
+		t.Fatal("expected error for relative name")
+	}
+}
@@ -3,3 +3,10 @@ module github.com/libdns/domainnameshop
 go 1.22.3
 
 require github.com/libdns/libdns v1.1.1
+
+require (
+	golang.org/x/net v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/text v0.22.0 // indirect
@@ -0,0 +1,108 @@
+package domainnameshop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// Operation is the kind of change a Mutation makes.
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+)
+
+// Mutation is a record change made through the Provider.
+type Mutation struct {
+	Zone      string
+	Operation Operation
+	// RecordID is the Domeneshop ID of the record, 0 before a record is created.
+	RecordID int
+	// Before is the record before the change, nil for creates and when the old state is unknown.
+	Before libdns.Record
+	// After is the record after the change, nil for deletes.
+	After libdns.Record
+}
+
+// MutationHook is called around every record create, update and delete made by the Provider,
+// including those made by higher level helpers like ImportZone and Restore.
+// Hooks must be safe for concurrent use.
+type MutationHook interface {
+	// BeforeMutation is called before the change is sent to the API.
+	// Returning an error aborts the change, the error is returned to the caller.
+	BeforeMutation(ctx context.Context, m Mutation) error
+	// AfterMutation is called after the change was attempted, with the error of the API call.
+	AfterMutation(ctx context.Context, m Mutation, err error)
+}
+
+// MutationHookFuncs is a MutationHook calling its functions, nil functions are skipped.
+type MutationHookFuncs struct {
+	Before func(ctx context.Context, m Mutation) error
+	After  func(ctx context.Context, m Mutation, err error)
+}
+
+func (h MutationHookFuncs) BeforeMutation(ctx context.Context, m Mutation) error {
+	if h.Before == nil {
+		return nil
+	}
+	return h.Before(ctx, m)
+}
+
+func (h MutationHookFuncs) AfterMutation(ctx context.Context, m Mutation, err error) {
+	if h.After != nil {
+		h.After(ctx, m, err)
+	}
+}
+
+// newMutation describes a change from before to after, either may be empty.
+func newMutation(zone string, op Operation, before dsDNSRecord, after dsDNSRecord) Mutation {
+	m := Mutation{
+		Zone:      removeFQDNTrailingDot(zone),
+		Operation: op,
+		RecordID:  before.ID,
+	}
+	if (dsDNSRecord{}) != before {
+		m.Before = hookRecord(before)
+	}
+	if (dsDNSRecord{}) != after {
+		m.After = hookRecord(after)
+		if after.ID != 0 {
+			m.RecordID = after.ID
+		}
+	}
+	return m
+}
+
+// hookRecord converts r for hooks, falling back to the generic form if it can't be parsed.
+func hookRecord(r dsDNSRecord) libdns.Record {
+	rec, err := r.libdnsRecord()
+	if err != nil {
+		return libdns.RR{Name: r.Host, Type: r.Type, Data: r.Data}
+	}
+	return rec
+}
+
+func (p *Provider) runBeforeHooks(ctx context.Context, m Mutation) error {
+	for _, h := range p.Hooks {
+		if err := h.BeforeMutation(ctx, m); err != nil {
+			name := ""
+			if rec := m.After; rec != nil {
+				name = rec.RR().Name + " " + rec.RR().Type
+			} else if rec := m.Before; rec != nil {
+				name = rec.RR().Name + " " + rec.RR().Type
+			}
+			return fmt.Errorf("%s of %s in %s aborted by hook: %w", m.Operation, name, m.Zone, err)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) runAfterHooks(ctx context.Context, m Mutation, err error) {
+	for _, h := range p.Hooks {
+		h.AfterMutation(ctx, m, err)
+	}
+}
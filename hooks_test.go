@@ -0,0 +1,148 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_MutationHookAborts(t *testing.T) {
+	errBlocked := errors.New("blocked by policy")
+
+	var seen []Mutation
+	afterCalled := false
+	p := &Provider{
+		zones: map[string]Domain{"example.com": {ID: 1, Name: "example.com"}},
+		Hooks: []MutationHook{MutationHookFuncs{
+			Before: func(ctx context.Context, m Mutation) error {
+				seen = append(seen, m)
+				return errBlocked
+			},
+			After: func(ctx context.Context, m Mutation, err error) {
+				afterCalled = true
+			},
+		}},
+	}
+
+	_, err := p.createDNSRecord(context.Background(), "", "", "example.com.", dsDNSRecord{Host: "www.example.com.", Type: "A", Data: "192.0.2.1"})
+	if !errors.Is(err, errBlocked) {
+		t.Fatalf("expected errBlocked => %v", err)
+	}
+
+	err = p.deleteDNSRecordByID(context.Background(), "", "", "example.com", dsDNSRecord{ID: 7, Host: "old", Type: "TXT", Data: "old"})
+	if !errors.Is(err, errBlocked) {
+		t.Fatalf("expected errBlocked => %v", err)
+	}
+
+	if afterCalled {
+		t.Fatal("AfterMutation called for aborted mutation")
+	}
+	if len(seen) != 2 {
+		t.Fatalf("len(seen) != 2 => %d", len(seen))
+	}
+
+	create := seen[0]
+	if create.Operation != OperationCreate || create.Zone != "example.com" || create.Before != nil || create.After.RR().Name != "www" {
+		t.Fatalf("unexpected create mutation => %+v", create)
+	}
+	del := seen[1]
+	if del.Operation != OperationDelete || del.RecordID != 7 || del.After != nil || del.Before.RR().Data != "old" {
+		t.Fatalf("unexpected delete mutation => %+v", del)
+	}
+}
+
+func Test_MutationHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/domains":
+			json.NewEncoder(w).Encode([]Domain{{ID: 1, Name: "example.com"}})
+		case r.Method == "GET" && r.URL.Path == "/domains/1/dns":
+			json.NewEncoder(w).Encode([]dsDNSRecord{{ID: 10, Host: "www", Type: "A", Data: "192.0.2.1", TTL: 3600}})
+		case r.Method == "POST":
+			json.NewEncoder(w).Encode(dsDNSRecord{ID: 11})
+		case r.URL.Path == "/domains/1/dns/12":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	type call struct {
+		m   Mutation
+		err error
+	}
+	var before, after []call
+	p := &Provider{
+		APIToken:  "token",
+		APISecret: "secret",
+		BaseURL:   server.URL,
+		Hooks: []MutationHook{MutationHookFuncs{
+			Before: func(ctx context.Context, m Mutation) error {
+				before = append(before, call{m: m})
+				return nil
+			},
+			After: func(ctx context.Context, m Mutation, err error) {
+				after = append(after, call{m: m, err: err})
+			},
+		}},
+	}
+	ctx := context.Background()
+
+	records, err := p.GetRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("GetRecords failed => %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.com", []libdns.Record{libdns.TXT{Name: "www", Text: "new"}}); err != nil {
+		t.Fatalf("AppendRecords failed => %v", err)
+	}
+	updated := records[0].(libdns.Address)
+	updated.IP = netip.MustParseAddr("192.0.2.2")
+	if _, err := p.SetRecords(ctx, "example.com", []libdns.Record{updated}); err != nil {
+		t.Fatalf("SetRecords failed => %v", err)
+	}
+	if _, err := p.DeleteRecords(ctx, "example.com", []libdns.Record{updated}); err != nil {
+		t.Fatalf("DeleteRecords failed => %v", err)
+	}
+	// The API fails the delete, which the after hook gets
+	err = p.deleteDNSRecordByID(ctx, "token", "secret", "example.com", dsDNSRecord{ID: 12, Host: "gone", Type: "TXT", Data: "gone"})
+	if err == nil {
+		t.Fatal("expected error deleting record 12")
+	}
+
+	if len(before) != 4 || len(after) != 4 {
+		t.Fatalf("hooks called %d and %d times, not 4", len(before), len(after))
+	}
+	for i := range after {
+		if after[i].m.Operation != before[i].m.Operation {
+			t.Fatalf("after hook %d for %s != %s", i, after[i].m.Operation, before[i].m.Operation)
+		}
+	}
+
+	create := after[0]
+	if create.err != nil || create.m.Operation != OperationCreate || create.m.RecordID != 11 || create.m.After.RR().Data != "new" {
+		t.Fatalf("unexpected create => %+v", create)
+	}
+	update := after[1]
+	if update.err != nil || update.m.Operation != OperationUpdate || update.m.RecordID != 10 ||
+		update.m.Before.RR().Data != "192.0.2.1" || update.m.After.RR().Data != "192.0.2.2" {
+		t.Fatalf("unexpected update => %+v", update)
+	}
+	if before[1].m.Before == nil || before[1].m.After == nil {
+		t.Fatalf("update before hook without Before or After => %+v", before[1].m)
+	}
+	del := after[2]
+	if del.err != nil || del.m.Operation != OperationDelete || del.m.RecordID != 10 || del.m.After != nil || del.m.Before.RR().Data != "192.0.2.2" {
+		t.Fatalf("unexpected delete => %+v", del)
+	}
+	failed := after[3]
+	if failed.err == nil || failed.m.Operation != OperationDelete || failed.m.RecordID != 12 {
+		t.Fatalf("unexpected failed delete => %+v", failed)
+	}
+}
@@ -0,0 +1,89 @@
+package domainnameshop
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// Internationalized names, like blåbær.no, are sent to the API in their punycode form,
+// xn--blbr-roah.no. Names are converted label by label and only labels with non-ASCII
+// characters are converted, since the IDNA rules don't allow the underscores of names
+// like _acme-challenge or the * of wildcards.
+
+// toASCIIName converts the Unicode labels of name to punycode, following IDNA 2008
+// with the UTS #46 mapping used for lookups, which also lower cases them.
+func toASCIIName(name string) (string, error) {
+	if isASCII(name) {
+		return name, nil
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+		ascii, err := idna.Lookup.ToASCII(label)
+		if err != nil {
+			return "", fmt.Errorf("invalid internationalized name %s: %v", name, err)
+		}
+		labels[i] = ascii
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// toUnicodeName converts the punycode labels of name to Unicode. Labels that aren't
+// valid punycode are kept as they are.
+func toUnicodeName(name string) string {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if len(label) < 4 || !strings.EqualFold(label[:4], "xn--") {
+			continue
+		}
+		if unicode, err := idna.Lookup.ToUnicode(strings.ToLower(label)); err == nil {
+			labels[i] = unicode
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// zoneKey is the form of zone used as key in caches, lower case punycode without
+// trailing dot, so both forms of a name find the same entries.
+func zoneKey(zone string) string {
+	zone = strings.ToLower(removeFQDNTrailingDot(zone))
+	if ascii, err := toASCIIName(zone); err == nil {
+		return ascii
+	}
+	return zone
+}
+
+// apiHost returns the host of the record named name in zone in the form the API
+// uses, relative to the zone and in punycode.
+func apiHost(name string, zone string) (string, error) {
+	ascii, err := toASCIIName(name)
+	if err != nil {
+		return "", err
+	}
+	return relativeName(ascii, zoneKey(zone))
+}
+
+// wantsUnicode reports whether any of names, given by the caller, is in Unicode form,
+// in which case names returned to the caller are converted to Unicode too.
+func wantsUnicode(names ...string) bool {
+	for _, name := range names {
+		if !isASCII(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package domainnameshop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_toASCIIName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"blåbær.no":                   "xn--blbr-roah.no",
+		"Blåbær.no.":                  "xn--blbr-roah.no.",
+		"_acme-challenge.ærlig.no":    "_acme-challenge.xn--rlig-uoa.no",
+		"*.øl.no":                     "*.xn--l-4ga.no",
+		"www.example.com":             "www.example.com",
+		"xn--blbr-roah.no":            "xn--blbr-roah.no",
+		"_acme-challenge.www.Example": "_acme-challenge.www.Example",
+	} {
+		ascii, err := toASCIIName(name)
+		if err != nil {
+			t.Fatalf("toASCIIName(%q) failed => %v", name, err)
+		}
+		if ascii != expected {
+			t.Fatalf("toASCIIName(%q) != %q => %q", name, expected, ascii)
+		}
+		if unicode := toUnicodeName(ascii); zoneKey(unicode) != zoneKey(ascii) {
+			t.Fatalf("toUnicodeName(%q) doesn't round trip => %q", ascii, unicode)
+		}
+	}
+
+	if _, err := toASCIIName("bad\u200d.no"); err == nil {
+		t.Fatal("expected error for invalid name")
+	}
+	if name := toUnicodeName("xn--zz.no"); name != "xn--zz.no" {
+		t.Fatalf("invalid punycode not kept => %q", name)
+	}
+}
+
+func Test_zoneKey(t *testing.T) {
+	for _, zone := range []string{"blåbær.no", "Blåbær.no.", "xn--blbr-roah.no", "XN--BLBR-ROAH.NO."} {
+		if key := zoneKey(zone); key != "xn--blbr-roah.no" {
+			t.Fatalf("zoneKey(%q) => %q", zone, key)
+		}
+	}
+
+	host, err := apiHost("www.blåbær.no.", "xn--blbr-roah.no")
+	if err != nil || host != "www" {
+		t.Fatalf("apiHost != www => %q, %v", host, err)
+	}
+	host, err = apiHost("ørret", "blåbær.no")
+	if err != nil || host != "xn--rret-fra" {
+		t.Fatalf("apiHost != xn--rret-fra => %q, %v", host, err)
+	}
+}
+
+func Test_GetRecords_IDN(t *testing.T) {
+	server := newTestAPI(t,
+		map[string][]Domain{"token": {{ID: 1, Name: "xn--blbr-roah.no"}}},
+		map[int][]dsDNSRecord{1: {{ID: 10, Host: "xn--rret-fra", Type: "A", Data: "192.0.2.1", TTL: 3600}}},
+	)
+	p := &Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}
+
+	for zone, expected := range map[string]string{
+		"blåbær.no.":       "ørret",
+		"xn--blbr-roah.no": "xn--rret-fra",
+	} {
+		records, err := p.GetRecords(context.Background(), zone)
+		if err != nil {
+			t.Fatalf("GetRecords(%q) failed => %v", zone, err)
+		}
+		if len(records) != 1 || records[0].RR().Name != expected {
+			t.Fatalf("GetRecords(%q) names != %q => %+v", zone, expected, records)
+		}
+	}
+	if len(p.zones) != 1 || len(p.knownRecords) != 1 {
+		t.Fatalf("both forms of the zone aren't cached once => %v, %v", p.zones, p.knownRecords)
+	}
+
+	zone, err := p.FindZone(context.Background(), "_acme-challenge.Blåbær.no")
+	if err != nil || zone != "blåbær.no" {
+		t.Fatalf("FindZone != blåbær.no => %q, %v", zone, err)
+	}
+	zone, err = p.FindZone(context.Background(), "_acme-challenge.xn--blbr-roah.no")
+	if err != nil || zone != "xn--blbr-roah.no" {
+		t.Fatalf("FindZone != xn--blbr-roah.no => %q, %v", zone, err)
+	}
+
+	domains, err := p.ListDomains(context.Background(), "blåbær.no")
+	if err != nil || len(domains) != 1 || domains[0].Name != "blåbær.no" {
+		t.Fatalf("ListDomains names not in Unicode => %+v, %v", domains, err)
+	}
+	domain, err := p.GetDomain(context.Background(), "Blåbær.no")
+	if err != nil || domain.Name != "blåbær.no" {
+		t.Fatalf("GetDomain name not in Unicode => %+v, %v", domain, err)
+	}
+	domain, err = p.GetDomain(context.Background(), "xn--blbr-roah.no")
+	if err != nil || domain.Name != "xn--blbr-roah.no" {
+		t.Fatalf("GetDomain name not in punycode => %+v, %v", domain, err)
+	}
+
+	groups, err := p.groupByZone(context.Background(), []libdns.Record{libdns.TXT{Name: "_acme-challenge.ørret.blåbær.no.", Text: "a"}})
+	if err != nil {
+		t.Fatalf("groupByZone failed => %v", err)
+	}
+	if groups[0].zone != "blåbær.no" || groups[0].records[0].RR().Name != "_acme-challenge.ørret" {
+		t.Fatalf("group not in the form of the name => %q %+v", groups[0].zone, groups[0].records)
+	}
+}
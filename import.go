@@ -0,0 +1,99 @@
+package domainnameshop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// ImportOptions configures ImportZone.
+type ImportOptions struct {
+	// Prune deletes live records that aren't among the imported records.
+	Prune bool
+	// DryRun computes the changes without making them.
+	DryRun bool
+}
+
+// ImportResult lists the changes ImportZone made, or would make with DryRun.
+type ImportResult struct {
+	Created []libdns.Record
+	Updated []libdns.Record
+	Deleted []libdns.Record
+}
+
+// ImportZone brings the live records of zone in line with records, typically from ParseZoneFile.
+// Records already present are left alone, only changing their TTL when it differs, and missing
+// records are created. See ImportOptions for deleting records that aren't imported.
+func (p *Provider) ImportZone(ctx context.Context, zone string, records []libdns.Record, opts ImportOptions) (_ ImportResult, err error) {
+	ctx, span := p.startSpan(ctx, "ImportZone", Attribute{AttrZone, zone}, Attribute{AttrRecordCount, len(records)})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	desired := make([]dsDNSRecord, 0, len(records))
+	for _, rec := range records {
+		dsrr, err := libdnsRecordTodsDNSRecord(rec)
+		if err != nil {
+			return ImportResult{}, err
+		}
+		if dsrr.Host, err = apiHost(dsrr.Host, zone); err != nil {
+			return ImportResult{}, err
+		}
+		desired = append(desired, dsrr)
+	}
+
+	live, err := p.getAllDomainRecords(ctx, token, secret, zone)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	plan := planChanges(live, desired, opts.Prune)
+	if !opts.DryRun {
+		plan, err = p.applyPlan(ctx, token, secret, zone, plan)
+	}
+
+	result, convErr := importResult(plan)
+	if err != nil {
+		return result, err
+	}
+	return result, convErr
+}
+
+func importResult(plan changePlan) (ImportResult, error) {
+	var result ImportResult
+	convert := func(r dsDNSRecord) (libdns.Record, error) {
+		rec, err := r.libdnsRecord()
+		if err != nil {
+			return nil, fmt.Errorf("parsing Domainnameshop DNS record %+v: %v", r, err)
+		}
+		return rec, nil
+	}
+
+	for _, r := range plan.create {
+		rec, err := convert(r)
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, rec)
+	}
+	for _, u := range plan.update {
+		rec, err := convert(u.after)
+		if err != nil {
+			return result, err
+		}
+		result.Updated = append(result.Updated, rec)
+	}
+	for _, r := range plan.delete {
+		rec, err := convert(r)
+		if err != nil {
+			return result, err
+		}
+		result.Deleted = append(result.Deleted, rec)
+	}
+
+	return result, nil
+}
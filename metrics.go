@@ -0,0 +1,213 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the API requests and caches of a Provider.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// RequestDone is called for every request sent, including retries. endpoint is the
+	// method and route template, like "GET /domains/{id}/dns". status is 0 if no
+	// response was received.
+	RequestDone(endpoint string, status int, latency time.Duration)
+	// RequestRetried is called before waiting to send a request again, rateLimited
+	// reports whether the API answered 429 Too Many Requests.
+	RequestRetried(endpoint string, wait time.Duration, rateLimited bool)
+	// CacheLookup is called for every lookup in the cache of zones or records.
+	CacheLookup(cache string, hit bool)
+}
+
+// Caches reported to Metrics.CacheLookup.
+const (
+	ZoneCache   = "zones"
+	RecordCache = "records"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histograms of ExpvarMetrics.
+var LatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Stats is a snapshot of the measurements of ExpvarMetrics.
+type Stats struct {
+	// Requests is keyed by endpoint.
+	Requests          map[string]EndpointStats `json:"requests"`
+	Retries           int64                    `json:"retries"`
+	RateLimitWaits    int64                    `json:"rate_limit_waits"`
+	RateLimitWaitTime time.Duration            `json:"rate_limit_wait_ns"`
+	// Caches is keyed by ZoneCache and RecordCache.
+	Caches map[string]CacheStats `json:"caches"`
+}
+
+// EndpointStats are the requests to an endpoint.
+type EndpointStats struct {
+	// Statuses counts the requests by status code, 0 for requests without response.
+	Statuses map[int]int64 `json:"statuses"`
+	Latency  Histogram     `json:"latency"`
+}
+
+// Histogram is a Prometheus-style histogram: Counts[i] is the number of observations
+// less than or equal to Buckets[i], the last count also includes larger ones.
+type Histogram struct {
+	Buckets []float64 `json:"buckets"`
+	Counts  []int64   `json:"counts"`
+	Count   int64     `json:"count"`
+	Sum     float64   `json:"sum"`
+}
+
+func (h *Histogram) observe(v float64) {
+	if h.Counts == nil {
+		h.Buckets = LatencyBuckets
+		h.Counts = make([]int64, len(h.Buckets)+1)
+	}
+	i := sort.SearchFloat64s(h.Buckets, v)
+	for ; i < len(h.Counts); i++ {
+		h.Counts[i]++
+	}
+	h.Count++
+	h.Sum += v
+}
+
+// CacheStats are the lookups in a cache.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// HitRatio is the fraction of lookups that were hits, 0 without lookups.
+func (c CacheStats) HitRatio() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// ExpvarMetrics is the default Metrics, keeping the measurements in memory.
+// It's an expvar.Var, so it can be published to serve them as JSON on /debug/vars.
+type ExpvarMetrics struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewExpvarMetrics returns unpublished ExpvarMetrics.
+func NewExpvarMetrics() *ExpvarMetrics {
+	return &ExpvarMetrics{stats: Stats{
+		Requests: make(map[string]EndpointStats),
+		Caches:   make(map[string]CacheStats),
+	}}
+}
+
+var (
+	defaultMetrics     *ExpvarMetrics
+	defaultMetricsOnce sync.Once
+)
+
+// DefaultMetrics returns the ExpvarMetrics used by Providers without Metrics. It's
+// published as the expvar "domainnameshop" the first time it's used.
+func DefaultMetrics() *ExpvarMetrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewExpvarMetrics()
+		expvar.Publish("domainnameshop", defaultMetrics)
+	})
+	return defaultMetrics
+}
+
+func (m *ExpvarMetrics) RequestDone(endpoint string, status int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.stats.Requests[endpoint]
+	if e.Statuses == nil {
+		e.Statuses = make(map[int]int64)
+	}
+	e.Statuses[status]++
+	e.Latency.observe(latency.Seconds())
+	m.stats.Requests[endpoint] = e
+}
+
+func (m *ExpvarMetrics) RequestRetried(endpoint string, wait time.Duration, rateLimited bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Retries++
+	if rateLimited {
+		m.stats.RateLimitWaits++
+		m.stats.RateLimitWaitTime += wait
+	}
+}
+
+func (m *ExpvarMetrics) CacheLookup(cache string, hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.stats.Caches[cache]
+	if hit {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+	m.stats.Caches[cache] = c
+}
+
+// Stats returns a copy of the measurements.
+func (m *ExpvarMetrics) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Requests = make(map[string]EndpointStats, len(m.stats.Requests))
+	for endpoint, e := range m.stats.Requests {
+		statuses := make(map[int]int64, len(e.Statuses))
+		for status, n := range e.Statuses {
+			statuses[status] = n
+		}
+		e.Statuses = statuses
+		e.Latency.Counts = append([]int64(nil), e.Latency.Counts...)
+		stats.Requests[endpoint] = e
+	}
+	stats.Caches = make(map[string]CacheStats, len(m.stats.Caches))
+	for cache, c := range m.stats.Caches {
+		stats.Caches[cache] = c
+	}
+	return stats
+}
+
+// String returns the measurements as JSON, for expvar.
+func (m *ExpvarMetrics) String() string {
+	b, err := json.Marshal(m.Stats())
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// metrics returns p.Metrics, or the default metrics if it's not set.
+func (p *Provider) metrics() Metrics {
+	if p.Metrics != nil {
+		return p.Metrics
+	}
+	return DefaultMetrics()
+}
+
+// Stats returns a snapshot of the measurements of the Provider, for debugging. Providers
+// without Metrics share the default metrics. It's empty if Metrics has no Stats method.
+func (p *Provider) Stats() Stats {
+	if m, ok := p.metrics().(interface{ Stats() Stats }); ok {
+		return m.Stats()
+	}
+	return Stats{}
+}
+
+// cacheLookup reports a lookup in one of the caches to the span in ctx and the metrics.
+func (p *Provider) cacheLookup(ctx context.Context, cache string, hit bool) {
+	key := AttrZoneCache
+	if cache == RecordCache {
+		key = AttrRecordCache
+	}
+	value := cacheMiss
+	if hit {
+		value = cacheHit
+	}
+	spanFromContext(ctx).SetAttributes(Attribute{key, value})
+	p.metrics().CacheLookup(cache, hit)
+}
@@ -0,0 +1,83 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_ExpvarMetrics(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	metrics := NewExpvarMetrics()
+	p := &Provider{
+		Metrics:    metrics,
+		MaxRetries: 1,
+		zones:      map[string]Domain{"example.com": {ID: 1, Name: "example.com"}},
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/domains/1/dns", nil)
+	if err := p.doRequest("", "", req, "/domains/{id}/dns", nil); err != nil {
+		t.Fatalf("doRequest failed => %v", err)
+	}
+	if _, err := p.GetDomain(context.Background(), "example.com"); err != nil {
+		t.Fatalf("GetDomain failed => %v", err)
+	}
+
+	stats := p.Stats()
+	e := stats.Requests["GET /domains/{id}/dns"]
+	if e.Statuses[http.StatusTooManyRequests] != 1 || e.Statuses[http.StatusOK] != 1 || e.Latency.Count != 2 {
+		t.Fatalf("unexpected endpoint stats => %+v", e)
+	}
+	if last := e.Latency.Counts[len(e.Latency.Counts)-1]; last != 2 {
+		t.Fatalf("last histogram count != 2 => %d", last)
+	}
+	if stats.Retries != 1 || stats.RateLimitWaits != 1 {
+		t.Fatalf("unexpected retries => %+v", stats)
+	}
+	if c := stats.Caches[ZoneCache]; c.Hits != 1 || c.Misses != 0 || c.HitRatio() != 1 {
+		t.Fatalf("unexpected zone cache stats => %+v", c)
+	}
+
+	// Stats is a copy
+	e.Statuses[http.StatusOK] = 100
+	if metrics.Stats().Requests["GET /domains/{id}/dns"].Statuses[http.StatusOK] != 1 {
+		t.Fatal("Stats shares its maps with the metrics")
+	}
+
+	var decoded Stats
+	if err := json.Unmarshal([]byte(metrics.String()), &decoded); err != nil {
+		t.Fatalf("String is not JSON => %v", err)
+	}
+}
+
+func Test_Histogram(t *testing.T) {
+	var h Histogram
+	for _, v := range []time.Duration{10 * time.Millisecond, 100 * time.Millisecond, 3 * time.Second, time.Minute} {
+		h.observe(v.Seconds())
+	}
+
+	// Buckets 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10 and the rest
+	expected := []int64{1, 2, 2, 2, 2, 2, 3, 3, 4}
+	for i := range expected {
+		if h.Counts[i] != expected[i] {
+			t.Fatalf("h.Counts != expected => %v != %v", h.Counts, expected)
+		}
+	}
+	if h.Count != 4 {
+		t.Fatalf("h.Count != 4 => %d", h.Count)
+	}
+}
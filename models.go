@@ -1,6 +1,7 @@
 package domainnameshop
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,19 +10,102 @@ import (
 	"github.com/libdns/libdns"
 )
 
-// dsZone JSON data structure.
-type dsZone struct {
-	Name           string   `json:"domain"`
-	ID             int      `json:"id"`
-	ExpiryDate     string   `json:"expiry_date"`
-	Nameservers    []string `json:"nameservers"`
-	RegisteredDate string   `json:"registered_date"`
-	Registrant     string   `json:"registrant"`
-	Renew          bool     `json:"renew"`
-	Services       Service  `json:"services"`
-	Status         string
+// Domain is a domain registered in the Domeneshop account.
+// https://api.domeneshop.no/docs/#tag/domains
+type Domain struct {
+	ID             int          `json:"id"`
+	Name           string       `json:"domain"`
+	ExpiryDate     time.Time    `json:"expiry_date"`
+	RegisteredDate time.Time    `json:"registered_date"`
+	Nameservers    []string     `json:"nameservers"`
+	Registrant     string       `json:"registrant"`
+	Renew          bool         `json:"renew"`
+	Services       Service      `json:"services"`
+	Status         DomainStatus `json:"status"`
 }
 
+// dsDomain is the wire format of Domain, the API sends dates as YYYY-MM-DD.
+type dsDomain struct {
+	ID             int          `json:"id"`
+	Name           string       `json:"domain"`
+	ExpiryDate     string       `json:"expiry_date,omitempty"`
+	RegisteredDate string       `json:"registered_date,omitempty"`
+	Nameservers    []string     `json:"nameservers"`
+	Registrant     string       `json:"registrant"`
+	Renew          bool         `json:"renew"`
+	Services       Service      `json:"services"`
+	Status         DomainStatus `json:"status"`
+}
+
+const dsDateLayout = "2006-01-02"
+
+func (d Domain) MarshalJSON() ([]byte, error) {
+	raw := dsDomain{
+		ID:          d.ID,
+		Name:        d.Name,
+		Nameservers: d.Nameservers,
+		Registrant:  d.Registrant,
+		Renew:       d.Renew,
+		Services:    d.Services,
+		Status:      d.Status,
+	}
+	if !d.ExpiryDate.IsZero() {
+		raw.ExpiryDate = d.ExpiryDate.Format(dsDateLayout)
+	}
+	if !d.RegisteredDate.IsZero() {
+		raw.RegisteredDate = d.RegisteredDate.Format(dsDateLayout)
+	}
+	return json.Marshal(raw)
+}
+
+func (d *Domain) UnmarshalJSON(data []byte) error {
+	var raw dsDomain
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	expiry, err := parseDSDate(raw.ExpiryDate)
+	if err != nil {
+		return fmt.Errorf("invalid expiry_date %s: %v", raw.ExpiryDate, err)
+	}
+	registered, err := parseDSDate(raw.RegisteredDate)
+	if err != nil {
+		return fmt.Errorf("invalid registered_date %s: %v", raw.RegisteredDate, err)
+	}
+
+	*d = Domain{
+		ID:             raw.ID,
+		Name:           raw.Name,
+		ExpiryDate:     expiry,
+		RegisteredDate: registered,
+		Nameservers:    raw.Nameservers,
+		Registrant:     raw.Registrant,
+		Renew:          raw.Renew,
+		Services:       raw.Services,
+		Status:         raw.Status,
+	}
+	return nil
+}
+
+func parseDSDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dsDateLayout, s)
+}
+
+// DomainStatus is the registration status of a Domain.
+type DomainStatus string
+
+const (
+	DomainStatusActive                  DomainStatus = "active"
+	DomainStatusExpired                 DomainStatus = "expired"
+	DomainStatusDeactivated             DomainStatus = "deactivated"
+	DomainStatusPendingDeleteRestorable DomainStatus = "pendingDeleteRestorable"
+)
+
+// Service lists the Domeneshop services enabled for a Domain.
+// Webhotel is "none" or the name of the webhotel product.
 type Service struct {
 	DNS       bool   `json:"dns"`
 	Email     bool   `json:"email"`
@@ -29,6 +113,14 @@ type Service struct {
 	Webhotel  string `json:"webhotel"`
 }
 
+// Forward is an HTTP forward of a host in a domain.
+// https://api.domeneshop.no/docs/#tag/forwards
+type Forward struct {
+	Host  string `json:"host" yaml:"host"`
+	Frame bool   `json:"frame" yaml:"frame"`
+	URL   string `json:"url" yaml:"url"`
+}
+
 // dsDNSRecord JSON data structure.
 // https://api.domeneshop.no/docs/#tag/dns_record_models
 type dsDNSRecord struct {
@@ -40,6 +132,45 @@ type dsDNSRecord struct {
 	Priority string `json:"priority,omitempty"`
 	Weight   string `json:"weight,omitempty"`
 	Port     string `json:"port,omitempty"`
+
+	// CAA
+	Flags dsField `json:"flags,omitempty"`
+	Tag   dsField `json:"tag,omitempty"` // Also the key tag of DS records
+	// TLSA
+	Usage    dsField `json:"usage,omitempty"`
+	Selector dsField `json:"selector,omitempty"`
+	DType    dsField `json:"dtype,omitempty"`
+	// DS
+	Alg    dsField `json:"alg,omitempty"`
+	Digest dsField `json:"digest,omitempty"` // The digest type, the digest itself is in Data
+}
+
+// dsField is a record field the API sends as either a number or a string depending on record type,
+// like the "tag" of CAA and DS records. It's sent back as a number when it is one.
+type dsField string
+
+func (f dsField) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseUint(string(f), 10, 64); err == nil {
+		return []byte(f), nil
+	}
+	return json.Marshal(string(f))
+}
+
+func (f *dsField) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*f = dsField(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*f = dsField(n)
+	return nil
 }
 
 func (r dsDNSRecord) libdnsRecord() (libdns.Record, error) {
@@ -71,15 +202,11 @@ func (r dsDNSRecord) libdnsRecord() (libdns.Record, error) {
 			return libdns.SRV{}, fmt.Errorf("invalid port %s: %v", r.Port, err)
 		}
 
-		parts := strings.SplitN(r.Host, ".", 2)
-		if len(parts) < 2 {
-			return libdns.SRV{}, fmt.Errorf("name %v does not contain enough fields; expected format: '_service._proto'", r.Host)
-		}
-
+		service, transport, name := splitSRVName(r.Host)
 		rr := libdns.SRV{
-			Service:   strings.TrimPrefix(parts[0], "_"),
-			Transport: strings.TrimPrefix(parts[1], "_"),
-			Name:      r.Host,
+			Service:   service,
+			Transport: transport,
+			Name:      name,
 			TTL:       time.Duration(r.TTL) * time.Second,
 			Priority:  uint16(priority),
 			Weight:    uint16(weight),
@@ -89,6 +216,46 @@ func (r dsDNSRecord) libdnsRecord() (libdns.Record, error) {
 
 		return rr, nil
 
+	case "TXT":
+		rr := libdns.TXT{
+			Name: r.Host,
+			TTL:  time.Duration(r.TTL) * time.Second,
+			Text: decodeTXT(r.Data),
+		}
+		return rr, nil
+
+	case "CAA":
+		flags, err := strconv.ParseUint(string(r.Flags), 10, 8)
+		if err != nil && r.Flags != "" {
+			return libdns.CAA{}, fmt.Errorf("invalid flags %s: %v", r.Flags, err)
+		}
+		rr := libdns.CAA{
+			Name:  r.Host,
+			TTL:   time.Duration(r.TTL) * time.Second,
+			Flags: uint8(flags),
+			Tag:   string(r.Tag),
+			Value: r.Data,
+		}
+		return rr, nil
+
+	case "TLSA":
+		rr := libdns.RR{
+			Name: r.Host,
+			TTL:  time.Duration(r.TTL) * time.Second,
+			Type: r.Type,
+			Data: fmt.Sprintf("%s %s %s %s", fieldOrZero(r.Usage), fieldOrZero(r.Selector), fieldOrZero(r.DType), r.Data),
+		}
+		return rr, nil
+
+	case "DS":
+		rr := libdns.RR{
+			Name: r.Host,
+			TTL:  time.Duration(r.TTL) * time.Second,
+			Type: r.Type,
+			Data: fmt.Sprintf("%s %s %s %s", fieldOrZero(r.Tag), fieldOrZero(r.Alg), fieldOrZero(r.Digest), r.Data),
+		}
+		return rr, nil
+
 	default:
 		rr := libdns.RR{
 			Name: r.Host,
@@ -102,6 +269,15 @@ func (r dsDNSRecord) libdnsRecord() (libdns.Record, error) {
 }
 
 func libdnsRecordTodsDNSRecord(r libdns.Record) (dsDNSRecord, error) {
+	r = valueRecord(r)
+	// Make sure we can tell the type specific fields apart
+	if generic, ok := r.(libdns.RR); ok {
+		parsed, err := generic.Parse()
+		if err != nil {
+			return dsDNSRecord{}, err
+		}
+		r = parsed
+	}
 	rr := r.RR()
 
 	dsRecord := dsDNSRecord{
@@ -114,14 +290,77 @@ func libdnsRecordTodsDNSRecord(r libdns.Record) (dsDNSRecord, error) {
 	switch rec := r.(type) {
 	case libdns.MX:
 		dsRecord.Priority = strconv.Itoa(int(rec.Preference))
+		dsRecord.Data = rec.Target
 
 	case libdns.SRV:
+		dsRecord.Host = srvName(rec.Service, rec.Transport, rec.Name)
 		dsRecord.Priority = strconv.Itoa(int(rec.Priority))
 		dsRecord.Port = strconv.Itoa(int(rec.Port))
 		dsRecord.Weight = strconv.Itoa(int(rec.Weight))
 		dsRecord.Data = rec.Target
+
+	case libdns.TXT:
+		dsRecord.Data = encodeTXT(rec.Text)
+
+	case libdns.CAA:
+		dsRecord.Flags = dsField(strconv.Itoa(int(rec.Flags)))
+		dsRecord.Tag = dsField(rec.Tag)
+		dsRecord.Data = rec.Value
+
+	case libdns.RR:
+		switch rec.Type {
+		case "TLSA":
+			fields := strings.Fields(rec.Data)
+			if len(fields) != 4 {
+				return dsDNSRecord{}, fmt.Errorf("malformed TLSA value; expected 4 fields in the form 'usage selector matching-type data'")
+			}
+			dsRecord.Usage, dsRecord.Selector, dsRecord.DType = dsField(fields[0]), dsField(fields[1]), dsField(fields[2])
+			dsRecord.Data = fields[3]
+
+		case "DS":
+			fields := strings.Fields(rec.Data)
+			if len(fields) != 4 {
+				return dsDNSRecord{}, fmt.Errorf("malformed DS value; expected 4 fields in the form 'key-tag algorithm digest-type digest'")
+			}
+			dsRecord.Tag, dsRecord.Alg, dsRecord.Digest = dsField(fields[0]), dsField(fields[1]), dsField(fields[2])
+			dsRecord.Data = fields[3]
+		}
 	}
 
 	return dsRecord, nil
 
 }
+
+// splitSRVName splits the host of an SRV record, like _sip._tcp.voice, into the service,
+// the transport and the name they're offered at, "@" for the zone itself. Hosts without
+// service and transport labels are returned as the name.
+func splitSRVName(host string) (service string, transport string, name string) {
+	parts := strings.SplitN(host, ".", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "_") || !strings.HasPrefix(parts[1], "_") {
+		return "", "", host
+	}
+	name = "@"
+	if len(parts) == 3 && parts[2] != "" {
+		name = parts[2]
+	}
+	return parts[0][1:], parts[1][1:], name
+}
+
+// srvName is the host of an SRV record for service and transport at name.
+func srvName(service string, transport string, name string) string {
+	if service == "" && transport == "" {
+		return name
+	}
+	host := "_" + service + "._" + transport
+	if name == "" || name == "@" {
+		return host
+	}
+	return host + "." + name
+}
+
+func fieldOrZero(f dsField) string {
+	if f == "" {
+		return "0"
+	}
+	return string(f)
+}
@@ -0,0 +1,137 @@
+package domainnameshop
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_DomainJSON(t *testing.T) {
+	data := `{"id":1,"domain":"example.com","expiry_date":"2026-04-02","registered_date":"2019-04-02",` +
+		`"renew":true,"registrant":"Ola Nordmann","status":"active","nameservers":["ns1.hyp.net","ns2.hyp.net"],` +
+		`"services":{"registrar":true,"dns":true,"email":false,"webhotel":"none"}}`
+
+	var d Domain
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC); !d.ExpiryDate.Equal(want) {
+		t.Fatalf("d.ExpiryDate != want => %s != %s", d.ExpiryDate, want)
+	}
+	if d.Status != DomainStatusActive {
+		t.Fatalf("d.Status != DomainStatusActive => %s", d.Status)
+	}
+	if !d.Services.DNS || d.Services.Email || d.Services.Webhotel != "none" {
+		t.Fatalf("unexpected services => %+v", d.Services)
+	}
+
+	out, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Domain
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !again.RegisteredDate.Equal(d.RegisteredDate) || again.Name != d.Name || len(again.Nameservers) != 2 {
+		t.Fatalf("round trip mismatch => %+v != %+v", again, d)
+	}
+}
+
+func Test_DomainJSONInvalidDate(t *testing.T) {
+	var d Domain
+	if err := json.Unmarshal([]byte(`{"id":1,"expiry_date":"02.04.2026"}`), &d); err == nil {
+		t.Fatal("expected error for invalid expiry_date")
+	}
+}
+
+func Test_dsFieldJSON(t *testing.T) {
+	out, err := json.Marshal(dsDNSRecord{Type: "DS", Tag: "12345", Alg: "13", Digest: "2", Data: "abcdef"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"data":"abcdef","type":"DS","tag":12345,"alg":13,"digest":2}`; string(out) != expected {
+		t.Fatalf("json.Marshal != expected => %s != %s", out, expected)
+	}
+
+	var r dsDNSRecord
+	if err := json.Unmarshal([]byte(`{"type":"CAA","flags":0,"tag":"issue","data":"letsencrypt.org"}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Flags != "0" || r.Tag != "issue" {
+		t.Fatalf("unexpected CAA fields => flags %q tag %q", r.Flags, r.Tag)
+	}
+}
+
+func Test_SRVRoundTrip(t *testing.T) {
+	for host, expected := range map[string]libdns.SRV{
+		"_sip._tcp.voice":  {Service: "sip", Transport: "tcp", Name: "voice"},
+		"_sip._tcp.a.b":    {Service: "sip", Transport: "tcp", Name: "a.b"},
+		"_xmpp._tcp":       {Service: "xmpp", Transport: "tcp", Name: "@"},
+		"_imaps._tcp.mail": {Service: "imaps", Transport: "tcp", Name: "mail"},
+		"voice":            {Name: "voice"},
+		"_dmarc.srv":       {Name: "_dmarc.srv"},
+	} {
+		expected.TTL, expected.Priority, expected.Weight, expected.Port, expected.Target = time.Hour, 10, 5, 5060, "sip.example.com"
+
+		rec, err := dsDNSRecord{Host: host, Type: "SRV", TTL: 3600, Priority: "10", Weight: "5", Port: "5060", Data: "sip.example.com"}.libdnsRecord()
+		if err != nil {
+			t.Fatalf("%s: converting from API record failed => %v", host, err)
+		}
+		if rec != libdns.Record(expected) {
+			t.Fatalf("%s: %+v != %+v", host, rec, expected)
+		}
+
+		dsrr, err := libdnsRecordTodsDNSRecord(rec)
+		if err != nil {
+			t.Fatalf("%s: converting to API record failed => %v", host, err)
+		}
+		if dsrr.Host != host || dsrr.Data != "sip.example.com" || dsrr.Port != "5060" {
+			t.Fatalf("%s: round trip mismatch => %+v", host, dsrr)
+		}
+	}
+
+	// Generic records are parsed by libdns, which puts the service labels in the name
+	dsrr, err := libdnsRecordTodsDNSRecord(libdns.RR{Name: "_sip._udp", Type: "SRV", Data: "0 0 5060 sip.example.com"})
+	if err != nil || dsrr.Host != "_sip._udp" {
+		t.Fatalf("apex SRV host != _sip._udp => %+v, %v", dsrr, err)
+	}
+	dsrr, err = libdnsRecordTodsDNSRecord(libdns.SRV{Service: "sip", Transport: "udp", Name: "", Port: 5060, Target: "sip.example.com"})
+	if err != nil || dsrr.Host != "_sip._udp" {
+		t.Fatalf("SRV without name host != _sip._udp => %+v, %v", dsrr, err)
+	}
+}
+
+func Test_libdnsRecordTodsDNSRecordPointers(t *testing.T) {
+	for _, c := range []struct {
+		rec      libdns.Record
+		expected dsDNSRecord
+	}{
+		{
+			&libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.com."},
+			dsDNSRecord{Host: "@", TTL: 3600, Type: "MX", Data: "mail.example.com.", Priority: "10"},
+		},
+		{
+			&libdns.SRV{Service: "sip", Transport: "tcp", Name: "voice", TTL: time.Hour, Priority: 1, Weight: 2, Port: 5060, Target: "sip.example.com."},
+			dsDNSRecord{Host: "_sip._tcp.voice", TTL: 3600, Type: "SRV", Data: "sip.example.com.", Priority: "1", Weight: "2", Port: "5060"},
+		},
+		{
+			&libdns.TXT{Name: "www", TTL: time.Hour, Text: `"quoted"`},
+			dsDNSRecord{Host: "www", TTL: 3600, Type: "TXT", Data: `"\"quoted\""`},
+		},
+		{
+			&libdns.RR{Name: "mail", TTL: time.Hour, Type: "MX", Data: "20 mx.example.com."},
+			dsDNSRecord{Host: "mail", TTL: 3600, Type: "MX", Data: "mx.example.com.", Priority: "20"},
+		},
+	} {
+		dsrr, err := libdnsRecordTodsDNSRecord(c.rec)
+		if err != nil {
+			t.Fatalf("%T: converting failed => %v", c.rec, err)
+		}
+		if dsrr != c.expected {
+			t.Fatalf("%T: %+v != %+v", c.rec, dsrr, c.expected)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// MultiProvider manages zones spread over several Domainname.shop accounts. Each zone
+// is routed to the account that owns it, found through the domain listings of the
+// accounts. The mapping is cached and refreshed when a zone isn't in it. Records passed
+// without zone go to the account whose FindZone finds the longest zone for their name.
+type MultiProvider struct {
+	// Accounts are providers with the credentials of each account.
+	Accounts []*Provider
+
+	owners   map[string]*Provider
+	ownersMu sync.Mutex
+}
+
+// Account returns the provider of the account owning zone. Accounts whose domains can't
+// be listed are skipped, their errors are only returned when no account owns the zone.
+func (m *MultiProvider) Account(ctx context.Context, zone string) (*Provider, error) {
+	name := zoneKey(zone)
+	if name == "" {
+		return nil, fmt.Errorf("no zone to find the account of")
+	}
+
+	m.ownersMu.Lock()
+	defer m.ownersMu.Unlock()
+	if p, ok := m.owners[name]; ok {
+		return p, nil
+	}
+
+	// Refresh the whole mapping, zones may have moved between accounts
+	owners := make(map[string]*Provider)
+	var errs []error
+	for i, p := range m.Accounts {
+		domains, err := p.ListDomains(ctx, "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listing domains of account %d: %w", i+1, err))
+			continue
+		}
+		for _, d := range domains {
+			key := zoneKey(d.Name)
+			if _, ok := owners[key]; !ok {
+				owners[key] = p
+			}
+		}
+	}
+	m.owners = owners
+
+	if p, ok := owners[name]; ok {
+		return p, nil
+	}
+	return nil, errors.Join(append([]error{fmt.Errorf("no account owns zone %s", zone)}, errs...)...)
+}
+
+// accountGroup is the records of one account when records with fully qualified names
+// are passed without zone.
+type accountGroup struct {
+	account *Provider
+	records []libdns.Record
+}
+
+// groupByAccount finds the account of each record, which must have a fully qualified
+// name, through the FindZone of each account. When several accounts have a zone
+// containing the name, the account with the longest zone is used.
+func (m *MultiProvider) groupByAccount(ctx context.Context, records []libdns.Record) ([]*accountGroup, error) {
+	var groups []*accountGroup
+	byAccount := make(map[*Provider]*accountGroup)
+	for _, rec := range records {
+		name := rec.RR().Name
+		var account *Provider
+		var zone string
+		var errs []error
+		for i, p := range m.Accounts {
+			found, err := p.FindZone(ctx, name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("account %d: %w", i+1, err))
+				continue
+			}
+			if len(zoneKey(found)) > len(zone) {
+				account, zone = p, zoneKey(found)
+			}
+		}
+		if account == nil {
+			return nil, errors.Join(append([]error{fmt.Errorf("no account has a zone containing %s", name)}, errs...)...)
+		}
+
+		g, ok := byAccount[account]
+		if !ok {
+			g = &accountGroup{account: account}
+			byAccount[account] = g
+			groups = append(groups, g)
+		}
+		g.records = append(g.records, rec)
+	}
+	return groups, nil
+}
+
+// forEachAccount runs the method of each account for its records, for methods called
+// without zone. method is a method expression like (*Provider).AppendRecords.
+func (m *MultiProvider) forEachAccount(ctx context.Context, records []libdns.Record, method func(p *Provider, ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)) ([]libdns.Record, error) {
+	groups, err := m.groupByAccount(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []libdns.Record
+	for _, g := range groups {
+		done, err := method(g.account, ctx, "", g.records)
+		results = append(results, done...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// GetRecords lists all the records in the zone.
+func (m *MultiProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	p, err := m.Account(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetRecords(ctx, zone)
+}
+
+// AppendRecords adds records to the zone. It returns the records that were added.
+func (m *MultiProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if zone == "" {
+		return m.forEachAccount(ctx, records, (*Provider).AppendRecords)
+	}
+	p, err := m.Account(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return p.AppendRecords(ctx, zone, records)
+}
+
+// SetRecords sets the records in the zone, either by updating existing records
+// or creating new ones. It returns the updated records.
+func (m *MultiProvider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if zone == "" {
+		return m.forEachAccount(ctx, records, (*Provider).SetRecords)
+	}
+	p, err := m.Account(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return p.SetRecords(ctx, zone, records)
+}
+
+// DeleteRecords deletes the records from the zone.
+func (m *MultiProvider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if zone == "" {
+		return m.forEachAccount(ctx, records, (*Provider).DeleteRecords)
+	}
+	p, err := m.Account(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return p.DeleteRecords(ctx, zone, records)
+}
+
+// Interface guards
+var (
+	_ libdns.RecordGetter   = (*MultiProvider)(nil)
+	_ libdns.RecordAppender = (*MultiProvider)(nil)
+	_ libdns.RecordSetter   = (*MultiProvider)(nil)
+	_ libdns.RecordDeleter  = (*MultiProvider)(nil)
+)
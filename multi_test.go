@@ -0,0 +1,134 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// newTestAPI serves the domain listing and DNS records of accounts, keyed by API token.
+// Created records get ID 100.
+func newTestAPI(t *testing.T, accounts map[string][]Domain, records map[int][]dsDNSRecord) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, _ := r.BasicAuth()
+		domains, ok := accounts[token]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/domains" {
+			json.NewEncoder(w).Encode(domains)
+			return
+		}
+		for _, d := range domains {
+			if r.URL.Path == "/domains/"+strconv.Itoa(d.ID)+"/dns" && r.Method == "POST" {
+				json.NewEncoder(w).Encode(dsDNSRecord{ID: 100})
+				return
+			}
+			if r.URL.Path == "/domains/"+strconv.Itoa(d.ID)+"/dns" {
+				json.NewEncoder(w).Encode(records[d.ID])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_MultiProvider(t *testing.T) {
+	server := newTestAPI(t,
+		map[string][]Domain{
+			"ours":     {{ID: 1, Name: "example.com"}},
+			"customer": {{ID: 2, Name: "example.net"}, {ID: 3, Name: "example.org"}},
+		},
+		map[int][]dsDNSRecord{
+			1: {{ID: 10, Host: "www", Type: "A", Data: "192.0.2.1", TTL: 3600}},
+			3: {{ID: 30, Host: "@", Type: "TXT", Data: "customer", TTL: 3600}},
+		},
+	)
+
+	ours := &Provider{APIToken: "ours", BaseURL: server.URL}
+	customer := &Provider{APIToken: "customer", BaseURL: server.URL}
+	m := &MultiProvider{Accounts: []*Provider{ours, customer}}
+
+	records, err := m.GetRecords(context.Background(), "example.org.")
+	if err != nil {
+		t.Fatalf("GetRecords failed => %v", err)
+	}
+	if len(records) != 1 || records[0].RR().Data != "customer" {
+		t.Fatalf("unexpected records => %+v", records)
+	}
+
+	p, err := m.Account(context.Background(), "Example.com")
+	if err != nil || p != ours {
+		t.Fatalf("example.com not routed to its account => %v", err)
+	}
+
+	if _, err := m.GetRecords(context.Background(), "example.info"); err == nil {
+		t.Fatal("expected error for unknown zone")
+	}
+}
+
+func Test_MultiProvider_FailingAccount(t *testing.T) {
+	server := newTestAPI(t,
+		map[string][]Domain{"customer": {{ID: 2, Name: "example.net"}}},
+		map[int][]dsDNSRecord{2: {{ID: 20, Host: "www", Type: "A", Data: "192.0.2.2", TTL: 3600}}},
+	)
+
+	revoked := &Provider{APIToken: "revoked", BaseURL: server.URL}
+	customer := &Provider{APIToken: "customer", BaseURL: server.URL}
+	m := &MultiProvider{Accounts: []*Provider{revoked, customer}}
+
+	p, err := m.Account(context.Background(), "example.net")
+	if err != nil || p != customer {
+		t.Fatalf("example.net not routed past the failing account => %v", err)
+	}
+
+	_, err = m.Account(context.Background(), "example.info")
+	if err == nil || !strings.Contains(err.Error(), "no account owns zone example.info") || !strings.Contains(err.Error(), "account 1") {
+		t.Fatalf("error doesn't tell the zone and the failing account => %v", err)
+	}
+}
+
+func Test_MultiProvider_WithoutZone(t *testing.T) {
+	server := newTestAPI(t,
+		map[string][]Domain{
+			"ours":     {{ID: 1, Name: "example.com"}},
+			"customer": {{ID: 2, Name: "example.net"}, {ID: 3, Name: "sub.example.com"}},
+		},
+		nil,
+	)
+
+	ours := &Provider{APIToken: "ours", BaseURL: server.URL}
+	customer := &Provider{APIToken: "customer", BaseURL: server.URL}
+	m := &MultiProvider{Accounts: []*Provider{ours, customer}}
+
+	added, err := m.AppendRecords(context.Background(), "", []libdns.Record{
+		libdns.TXT{Name: "_acme-challenge.example.net.", Text: "a"},
+		libdns.TXT{Name: "_acme-challenge.www.example.com.", Text: "b"},
+		libdns.TXT{Name: "_acme-challenge.sub.example.com.", Text: "c"},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords failed => %v", err)
+	}
+	if len(added) != 3 {
+		t.Fatalf("unexpected records => %+v", added)
+	}
+	// Records come back grouped by account, sub.example.com goes to the longest zone
+	for i, expected := range []string{"_acme-challenge.example.net.", "_acme-challenge.sub.example.com.", "_acme-challenge.www.example.com."} {
+		if added[i].RR().Name != expected {
+			t.Fatalf("record %d != %s => %+v", i, expected, added)
+		}
+	}
+
+	if _, err := m.AppendRecords(context.Background(), "", []libdns.Record{libdns.TXT{Name: "_acme-challenge.example.info.", Text: "a"}}); err == nil {
+		t.Fatal("expected error for a name in no account")
+	}
+}
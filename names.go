@@ -0,0 +1,84 @@
+package domainnameshop
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RelativeName returns the host of the record named name in zone, like the Provider
+// resolves the names of the records it's given: "@" for the zone itself and the labels in
+// front of the zone for names in it, which may be fully qualified with or without trailing
+// dot. Fully qualified names outside the zone are an error, and so is a name like
+// example.com.example.com without trailing dot in example.com, which could mean either host.
+// Internationalized names may be in either form, the host is in Unicode if name or zone is.
+func RelativeName(name string, zone string) (string, error) {
+	host, err := apiHost(name, zone)
+	if err != nil {
+		return "", err
+	}
+	if wantsUnicode(name, zone) {
+		host = toUnicodeName(host)
+	}
+	return host, nil
+}
+
+// relativeName returns the host of the record named name in zone, as the API wants it:
+// "@" for the zone itself and the labels in front of the zone for names in it. Like
+// libdns.RelativeName, names with or without trailing dot ending in the zone are taken to be
+// fully qualified, but the zone only matches whole labels, so fooexample.com stays
+// fooexample.com in example.com. Case doesn't matter.
+//
+// Fully qualified names outside the zone are an error. So is a name without trailing dot
+// like example.com.example.com in example.com, which could be either the host example.com
+// or the host example.com.example.com, as it doesn't say which is meant.
+func relativeName(name string, zone string) (string, error) {
+	fqdn := strings.HasSuffix(name, ".")
+	name = removeFQDNTrailingDot(name)
+	zone = removeFQDNTrailingDot(zone)
+	if name == "" || name == "@" || strings.EqualFold(name, zone) {
+		return "@", nil
+	}
+	if zone == "" {
+		return name, nil
+	}
+	host, ok := cutZone(name, zone)
+	switch {
+	case !ok && fqdn:
+		return "", fmt.Errorf("%s. is not in zone %s", name, zone)
+	case !ok:
+		return name, nil
+	case !fqdn && (strings.EqualFold(host, zone) || hasZoneSuffix(host, zone)):
+		return "", fmt.Errorf("%s is ambiguous in zone %s, add a trailing dot if it is fully qualified", name, zone)
+	}
+	return host, nil
+}
+
+// cutZone returns the labels of name in front of zone, if name ends in zone.
+func cutZone(name string, zone string) (string, bool) {
+	if !hasZoneSuffix(name, zone) {
+		return "", false
+	}
+	return name[:len(name)-len(zone)-1], true
+}
+
+// hasZoneSuffix reports whether name is in zone, but not zone itself.
+func hasZoneSuffix(name string, zone string) bool {
+	prefix := len(name) - len(zone) - 1
+	return prefix > 0 && name[prefix] == '.' && strings.EqualFold(name[prefix+1:], zone)
+}
+
+// absoluteName returns the fully qualified name, with trailing dot, of the record
+// with host name in zone. Names with a trailing dot are fully qualified already.
+func absoluteName(name string, zone string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	zone = removeFQDNTrailingDot(zone)
+	if name == "" || name == "@" {
+		return zone + "."
+	}
+	if zone == "" {
+		return name + "."
+	}
+	return name + "." + zone + "."
+}
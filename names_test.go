@@ -0,0 +1,89 @@
+package domainnameshop
+
+import "testing"
+
+func Test_relativeName(t *testing.T) {
+	for _, c := range []struct {
+		name, zone, want string
+	}{
+		{"@", "example.com", "@"},
+		{"", "example.com.", "@"},
+		{"example.com", "example.com", "@"},
+		{"Example.COM.", "example.com", "@"},
+		{"www", "example.com", "www"},
+		{"www.example.com", "example.com", "www"},
+		{"www.example.com.", "example.com.", "www"},
+		{"a.b.example.com.", "example.com", "a.b"},
+		{"*", "example.com", "*"},
+		{"*.example.com.", "example.com", "*"},
+		{"*.sub.example.com", "example.com.", "*.sub"},
+		{"fooexample.com", "example.com", "fooexample.com"},
+		{"example.com.example.com.", "example.com.", "example.com"},
+		{"www.example.com.example.com.", "example.com", "www.example.com"},
+		{"_acme-challenge.example.com", "example.com", "_acme-challenge"},
+	} {
+		got, err := relativeName(c.name, c.zone)
+		if err != nil || got != c.want {
+			t.Errorf("relativeName(%q, %q) != %q => %q, %v", c.name, c.zone, c.want, got, err)
+		}
+	}
+
+	for _, c := range []struct {
+		name, zone string
+	}{
+		// Fully qualified names outside the zone
+		{"www.example.net.", "example.com"},
+		{"fooexample.com.", "example.com"},
+		{"example.com.", "sub.example.com"},
+		// Either the host example.com or example.com.example.com
+		{"example.com.example.com", "example.com"},
+		{"www.example.com.example.com", "example.com"},
+	} {
+		if got, err := relativeName(c.name, c.zone); err == nil {
+			t.Errorf("relativeName(%q, %q) not an error => %q", c.name, c.zone, got)
+		}
+	}
+}
+
+func Test_RelativeName(t *testing.T) {
+	for _, c := range []struct {
+		name, zone, want string
+	}{
+		{"www.example.com", "example.com", "www"},
+		{"www.xn--blbr-roah.no.", "blåbær.no", "www"},
+		{"Bløgg.blåbær.no", "xn--blbr-roah.no", "bløgg"},
+		{"xn--blgg-hra.xn--blbr-roah.no", "xn--blbr-roah.no", "xn--blgg-hra"},
+	} {
+		got, err := RelativeName(c.name, c.zone)
+		if err != nil || got != c.want {
+			t.Errorf("RelativeName(%q, %q) != %q => %q, %v", c.name, c.zone, c.want, got, err)
+		}
+	}
+	if got, err := RelativeName("www.blåbær.no.", "example.com"); err == nil {
+		t.Errorf("name outside the zone not an error => %q", got)
+	}
+}
+
+func Test_absoluteName(t *testing.T) {
+	for _, c := range []struct {
+		name, zone, want string
+	}{
+		{"@", "example.com", "example.com."},
+		{"", "example.com.", "example.com."},
+		{"www", "example.com", "www.example.com."},
+		{"www", "example.com.", "www.example.com."},
+		{"*", "example.com", "*.example.com."},
+		{"www.example.com.", "example.com", "www.example.com."},
+	} {
+		if got := absoluteName(c.name, c.zone); got != c.want {
+			t.Errorf("absoluteName(%q, %q) != %q => %q", c.name, c.zone, c.want, got)
+		}
+	}
+
+	// Relative names survive the round trip
+	for _, name := range []string{"@", "www", "*", "*.sub", "fooexample.com", "example.com"} {
+		if got, err := relativeName(absoluteName(name, "example.com"), "example.com."); err != nil || got != name {
+			t.Errorf("%q != %q after round trip => %v", got, name, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package domainnameshop
+
+import (
+	"context"
+	"strings"
+)
+
+// changePlan is the set of API calls that bring a zone from its live records to the desired ones.
+type changePlan struct {
+	create []dsDNSRecord
+	update []recordUpdate
+	delete []dsDNSRecord
+}
+
+type recordUpdate struct {
+	before dsDNSRecord
+	after  dsDNSRecord
+}
+
+func (c changePlan) empty() bool {
+	return len(c.create) == 0 && len(c.update) == 0 && len(c.delete) == 0
+}
+
+// recordKey identifies the content of a record regardless of its ID and TTL.
+func recordKey(r dsDNSRecord) dsDNSRecord {
+	r.ID = 0
+	r.TTL = 0
+	r.Host = strings.ToLower(r.Host)
+	switch r.Type {
+	case "CNAME", "MX", "NS", "SRV", "ANAME":
+		r.Data = strings.ToLower(removeFQDNTrailingDot(r.Data))
+	}
+	return r
+}
+
+// planChanges compares the live records of a zone with the desired records, both with hosts
+// normalized to the zone. Records with the same content are kept, changing only the TTL if
+// needed. With prune, live records that aren't desired are deleted, or updated in place when
+// a desired record with the same ID, or without ID but the same host and type, needs to be created.
+func planChanges(live []dsDNSRecord, desired []dsDNSRecord, prune bool) changePlan {
+	var plan changePlan
+
+	liveByKey := make(map[dsDNSRecord][]dsDNSRecord)
+	for _, rec := range live {
+		liveByKey[recordKey(rec)] = append(liveByKey[recordKey(rec)], rec)
+	}
+
+	var unmatched []dsDNSRecord
+	for _, want := range desired {
+		key := recordKey(want)
+		matches := liveByKey[key]
+		if len(matches) == 0 {
+			unmatched = append(unmatched, want)
+			continue
+		}
+		have := matches[0]
+		liveByKey[key] = matches[1:]
+
+		if want.TTL != 0 && want.TTL != have.TTL {
+			want.ID = have.ID
+			plan.update = append(plan.update, recordUpdate{before: have, after: want})
+		}
+	}
+
+	// Keep the order of the live records for predictable plans
+	var leftover []dsDNSRecord
+	for _, rec := range live {
+		key := recordKey(rec)
+		for _, r := range liveByKey[key] {
+			if r.ID == rec.ID {
+				leftover = append(leftover, rec)
+				break
+			}
+		}
+	}
+
+	for _, want := range unmatched {
+		if prune {
+			reused := false
+			for i, have := range leftover {
+				if want.ID != 0 && want.ID == have.ID || want.ID == 0 && strings.EqualFold(have.Host, want.Host) && have.Type == want.Type {
+					want.ID = have.ID
+					plan.update = append(plan.update, recordUpdate{before: have, after: want})
+					leftover = append(leftover[:i], leftover[i+1:]...)
+					reused = true
+					break
+				}
+			}
+			if reused {
+				continue
+			}
+		}
+		// IDs of records that are gone can't be reused
+		want.ID = 0
+		plan.create = append(plan.create, want)
+	}
+
+	if prune {
+		plan.delete = leftover
+	}
+
+	return plan
+}
+
+// applyPlan runs the changes of plan against the zone, deleting first so replaced
+// records don't conflict with new ones. It returns the changes that were made before any error.
+func (p *Provider) applyPlan(ctx context.Context, token string, secret string, zone string, plan changePlan) (changePlan, error) {
+	var applied changePlan
+
+	for _, rec := range plan.delete {
+		if err := p.deleteDNSRecordByID(ctx, token, secret, zone, rec); err != nil {
+			return applied, err
+		}
+		applied.delete = append(applied.delete, rec)
+	}
+
+	for _, u := range plan.update {
+		result, err := p.updateDNSRecord(ctx, token, secret, zone, u.after)
+		if err != nil {
+			return applied, err
+		}
+		applied.update = append(applied.update, recordUpdate{before: u.before, after: result})
+	}
+
+	for _, rec := range plan.create {
+		result, err := p.createDNSRecord(ctx, token, secret, zone, rec)
+		if err != nil {
+			return applied, err
+		}
+		applied.create = append(applied.create, result)
+	}
+
+	return applied, nil
+}
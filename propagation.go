@@ -0,0 +1,348 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// PropagationOptions configures WaitForPropagation.
+type PropagationOptions struct {
+	// Timeout is how long to wait for the records, 2 minutes if zero.
+	Timeout time.Duration
+	// Interval is the time between queries of a nameserver, 5 seconds if zero.
+	Interval time.Duration
+	// Resolvers overrides the nameservers of the zone, as host:port addresses.
+	Resolvers []string
+}
+
+const (
+	defaultPropagationTimeout  = 2 * time.Minute
+	defaultPropagationInterval = 5 * time.Second
+	// dnsQueryTimeout is how long to wait for the answer to a single query.
+	dnsQueryTimeout = 5 * time.Second
+)
+
+// expectedAnswer is a record WaitForPropagation waits for.
+type expectedAnswer struct {
+	name  string // Fully qualified, lower case
+	qtype dnsmessage.Type
+	data  string // In the format of answerData
+}
+
+func (e expectedAnswer) String() string {
+	return fmt.Sprintf("%s %s %s", e.name, strings.TrimPrefix(e.qtype.String(), "Type"), e.data)
+}
+
+// WaitForPropagation waits until each authoritative nameserver of the zone serves all
+// records, which have names relative to the zone like those from AppendRecords or names in
+// the zone like www.example.com, with or without trailing dot. The
+// nameservers are queried directly over UDP, or TCP for truncated answers, ignoring any caches.
+// Supported record types are A, AAAA, CAA, CNAME, MX, NS, SRV and TXT.
+func (p *Provider) WaitForPropagation(ctx context.Context, zone string, records []libdns.Record, opts PropagationOptions) (err error) {
+	ctx, span := p.startSpan(ctx, "WaitForPropagation", Attribute{AttrZone, zone}, Attribute{AttrRecordCount, len(records)})
+	defer func() { endSpan(span, err) }()
+
+	expected := make([]expectedAnswer, 0, len(records))
+	for _, rec := range records {
+		e, err := newExpectedAnswer(rec, zone)
+		if err != nil {
+			return err
+		}
+		expected = append(expected, e)
+	}
+
+	servers := opts.Resolvers
+	if len(servers) == 0 {
+		token, secret, err := p.credentials(ctx)
+		if err != nil {
+			return err
+		}
+		domain, err := p.getDomainInfo(ctx, token, secret, zone)
+		if err != nil {
+			return err
+		}
+		if len(domain.Nameservers) == 0 {
+			return fmt.Errorf("no nameservers for %s", zone)
+		}
+		for _, ns := range domain.Nameservers {
+			servers = append(servers, net.JoinHostPort(removeFQDNTrailingDot(ns), "53"))
+		}
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultPropagationTimeout
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultPropagationInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Nameservers are polled in turn until each has served every record once
+	pending := make(map[string][]expectedAnswer, len(servers))
+	for _, server := range servers {
+		pending[server] = expected
+	}
+	var lastErr error
+	for {
+		for _, server := range servers {
+			var missing []expectedAnswer
+			for _, e := range pending[server] {
+				served, err := queryServed(ctx, server, e)
+				if err != nil {
+					lastErr = fmt.Errorf("querying %s: %v", server, err)
+				}
+				if !served {
+					missing = append(missing, e)
+				}
+			}
+			if len(missing) == 0 {
+				delete(pending, server)
+			} else {
+				pending[server] = missing
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return propagationError(ctx.Err(), pending, lastErr)
+		}
+	}
+}
+
+func propagationError(err error, pending map[string][]expectedAnswer, lastErr error) error {
+	servers := make([]string, 0, len(pending))
+	for server := range pending {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+
+	var missing []string
+	for _, server := range servers {
+		for _, e := range pending[server] {
+			missing = append(missing, fmt.Sprintf("%s at %s", e, server))
+		}
+	}
+	err = fmt.Errorf("records not propagated: %w; missing %s", err, strings.Join(missing, ", "))
+	if lastErr != nil {
+		err = fmt.Errorf("%w; last error: %v", err, lastErr)
+	}
+	return err
+}
+
+func newExpectedAnswer(rec libdns.Record, zone string) (expectedAnswer, error) {
+	rr := rec.RR()
+	// Names already in the zone, like www.example.com without trailing dot, aren't appended to it again
+	host, err := apiHost(rr.Name, zone)
+	if err != nil {
+		return expectedAnswer{}, err
+	}
+	e := expectedAnswer{name: canonicalName(absoluteName(host, zoneKey(zone)))}
+
+	parsed, err := rr.Parse()
+	if err != nil {
+		return e, err
+	}
+	switch r := parsed.(type) {
+	case libdns.Address:
+		e.qtype = dnsmessage.TypeAAAA
+		if r.IP.Is4() {
+			e.qtype = dnsmessage.TypeA
+		}
+		e.data = r.IP.String()
+	case libdns.CNAME:
+		e.qtype, e.data = dnsmessage.TypeCNAME, canonicalName(r.Target)
+	case libdns.NS:
+		e.qtype, e.data = dnsmessage.TypeNS, canonicalName(r.Target)
+	case libdns.MX:
+		e.qtype, e.data = dnsmessage.TypeMX, fmt.Sprintf("%d %s", r.Preference, canonicalName(r.Target))
+	case libdns.SRV:
+		e.qtype, e.data = dnsmessage.TypeSRV, fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, canonicalName(r.Target))
+	case libdns.TXT:
+		e.qtype, e.data = dnsmessage.TypeTXT, r.Text
+	case libdns.CAA:
+		e.qtype, e.data = typeCAA, fmt.Sprintf("%d %s %s", r.Flags, strings.ToLower(r.Tag), r.Value)
+	default:
+		return e, fmt.Errorf("can't check propagation of %s record %s", rr.Type, rr.Name)
+	}
+	return e, nil
+}
+
+// typeCAA is the CAA record type, which dnsmessage doesn't define.
+const typeCAA dnsmessage.Type = 257
+
+// canonicalName is name fully qualified, in lower case and punycode, for queries and comparisons.
+func canonicalName(name string) string {
+	return zoneKey(name) + "."
+}
+
+// queryServed reports whether server answers the query for e with e.data among the answers.
+func queryServed(ctx context.Context, server string, e expectedAnswer) (bool, error) {
+	answers, err := queryNameserver(ctx, server, e.name, e.qtype)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range answers {
+		if a == e.data {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// queryNameserver returns the data of the answers of server for name and type,
+// in the format of answerData.
+func queryNameserver(ctx context.Context, server string, name string, qtype dnsmessage.Type) ([]string, error) {
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Uint32())
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(1232, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := exchange(ctx, "udp", server, query)
+	if err != nil {
+		return nil, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, err
+	}
+	if msg.Truncated {
+		if response, err = exchange(ctx, "tcp", server, query); err != nil {
+			return nil, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, err
+		}
+	}
+	if msg.ID != id {
+		return nil, errors.New("answer doesn't match query")
+	}
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+	default:
+		return nil, fmt.Errorf("answer for %s: %v", name, msg.RCode)
+	}
+
+	var answers []string
+	for _, a := range msg.Answers {
+		if a.Header.Type != qtype || !strings.EqualFold(a.Header.Name.String(), qname.String()) {
+			continue
+		}
+		if data, ok := answerData(a); ok {
+			answers = append(answers, data)
+		}
+	}
+	return answers, nil
+}
+
+// answerData formats the data of an answer like expectedAnswer.
+func answerData(a dnsmessage.Resource) (string, bool) {
+	switch body := a.Body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(body.A[:]).String(), true
+	case *dnsmessage.AAAAResource:
+		return net.IP(body.AAAA[:]).String(), true
+	case *dnsmessage.CNAMEResource:
+		return canonicalName(body.CNAME.String()), true
+	case *dnsmessage.NSResource:
+		return canonicalName(body.NS.String()), true
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", body.Pref, canonicalName(body.MX.String())), true
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, canonicalName(body.Target.String())), true
+	case *dnsmessage.TXTResource:
+		return strings.Join(body.TXT, ""), true
+	case *dnsmessage.UnknownResource:
+		// CAA: flags, tag length, tag and value
+		if body.Type != typeCAA || len(body.Data) < 2 || len(body.Data) < 2+int(body.Data[1]) {
+			return "", false
+		}
+		tag := string(body.Data[2 : 2+body.Data[1]])
+		value := string(body.Data[2+body.Data[1]:])
+		return strconv.Itoa(int(body.Data[0])) + " " + strings.ToLower(tag) + " " + value, true
+	}
+	return "", false
+}
+
+// exchange sends a DNS message to server and returns the response, over TCP
+// with the two byte length prefix.
+func exchange(ctx context.Context, network string, server string, query []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		msg := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(msg, uint16(len(query)))
+		copy(msg[2:], query)
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		response := make([]byte, binary.BigEndian.Uint16(length[:]))
+		_, err := io.ReadFull(conn, response)
+		return response, err
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	response := make([]byte, 65535)
+	n, err := conn.Read(response)
+	return response[:n], err
+}
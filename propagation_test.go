@@ -0,0 +1,206 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testNameserver is a local DNS stand-in answering over UDP and TCP on the same port.
+type testNameserver struct {
+	addr    string
+	queries atomic.Int32
+	// answer returns the answers to a question, and whether the UDP answer is truncated.
+	answer func(q dnsmessage.Question, udp bool) ([]dnsmessage.Resource, bool)
+}
+
+func startTestNameserver(t *testing.T, answer func(q dnsmessage.Question, udp bool) ([]dnsmessage.Resource, bool)) *testNameserver {
+	t.Helper()
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		tcp.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		tcp.Close()
+		udp.Close()
+	})
+
+	ns := &testNameserver{addr: tcp.Addr().String(), answer: answer}
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if response := ns.respond(buf[:n], true); response != nil {
+				udp.WriteTo(response, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					response := ns.respond(query, false)
+					binary.BigEndian.PutUint16(length[:], uint16(len(response)))
+					conn.Write(append(length[:], response...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return ns
+}
+
+func (ns *testNameserver) respond(query []byte, udp bool) []byte {
+	ns.queries.Add(1)
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+	answers, truncated := ns.answer(msg.Questions[0], udp)
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.ID, Response: true, Authoritative: true, Truncated: truncated && udp},
+		Questions: msg.Questions,
+	}
+	if !response.Truncated {
+		response.Answers = answers
+	}
+	b, err := response.Pack()
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func Test_WaitForPropagation(t *testing.T) {
+	name := dnsmessage.MustNewName("_acme-challenge.example.com.")
+	txt := func(text string) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.TXTResource{TXT: []string{text}},
+		}
+	}
+
+	// The first nameserver serves the record after a few queries, the second one over TCP only
+	var polls atomic.Int32
+	slow := startTestNameserver(t, func(q dnsmessage.Question, udp bool) ([]dnsmessage.Resource, bool) {
+		if q.Type != dnsmessage.TypeTXT || polls.Add(1) < 3 {
+			return nil, false
+		}
+		return []dnsmessage.Resource{txt("other"), txt("token")}, false
+	})
+	truncating := startTestNameserver(t, func(q dnsmessage.Question, udp bool) ([]dnsmessage.Resource, bool) {
+		return []dnsmessage.Resource{txt("token")}, true
+	})
+
+	p := &Provider{}
+	records := []libdns.Record{libdns.TXT{Name: "_acme-challenge", Text: "token"}}
+	err := p.WaitForPropagation(context.Background(), "example.com.", records, PropagationOptions{
+		Timeout:   5 * time.Second,
+		Interval:  10 * time.Millisecond,
+		Resolvers: []string{slow.addr, truncating.addr},
+	})
+	if err != nil {
+		t.Fatalf("WaitForPropagation failed => %v", err)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Fatalf("polls != 3 => %d", n)
+	}
+	// One query over UDP and one over TCP, the record was served after that
+	if n := truncating.queries.Load(); n != 2 {
+		t.Fatalf("truncating.queries != 2 => %d", n)
+	}
+}
+
+func Test_WaitForPropagationTimeout(t *testing.T) {
+	ns := startTestNameserver(t, func(q dnsmessage.Question, udp bool) ([]dnsmessage.Resource, bool) {
+		return []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+		}}, false
+	})
+
+	p := &Provider{}
+	records := []libdns.Record{libdns.Address{Name: "www", IP: netip.MustParseAddr("192.0.2.2")}}
+	err := p.WaitForPropagation(context.Background(), "example.com", records, PropagationOptions{
+		Timeout:   100 * time.Millisecond,
+		Interval:  10 * time.Millisecond,
+		Resolvers: []string{ns.addr},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded => %v", err)
+	}
+	if !strings.Contains(err.Error(), "www.example.com. A 192.0.2.2") {
+		t.Fatalf("error doesn't name the missing record => %v", err)
+	}
+}
+
+func Test_answerData(t *testing.T) {
+	name := dnsmessage.MustNewName("example.com.")
+	header := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET}
+	tests := []struct {
+		record libdns.Record
+		answer dnsmessage.ResourceBody
+	}{
+		{libdns.MX{Name: "@", Preference: 10, Target: "Mail.example.com"}, &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.com.")}},
+		{libdns.CNAME{Name: "@", Target: "target.example.net."}, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("target.example.net.")}},
+		{libdns.Address{Name: "@", IP: netip.MustParseAddr("2001:db8::1")}, &dnsmessage.AAAAResource{AAAA: netip.MustParseAddr("2001:db8::1").As16()}},
+		{libdns.CAA{Name: "@", Flags: 0, Tag: "issue", Value: "letsencrypt.org"}, &dnsmessage.UnknownResource{Type: typeCAA, Data: append([]byte{0, 5}, "issueletsencrypt.org"...)}},
+	}
+	for _, test := range tests {
+		expected, err := newExpectedAnswer(test.record, "example.com")
+		if err != nil {
+			t.Fatalf("newExpectedAnswer(%+v) failed => %v", test.record, err)
+		}
+		data, ok := answerData(dnsmessage.Resource{Header: header, Body: test.answer})
+		if !ok || data != expected.data {
+			t.Fatalf("answerData != expected.data => %q != %q", data, expected.data)
+		}
+	}
+}
+
+func Test_newExpectedAnswerNames(t *testing.T) {
+	for name, expected := range map[string]string{
+		"www":               "www.example.com.",
+		"@":                 "example.com.",
+		"www.example.com":   "www.example.com.",
+		"WWW.Example.com.":  "www.example.com.",
+		"example.com":       "example.com.",
+		"ørret.example.com": "xn--rret-fra.example.com.",
+	} {
+		e, err := newExpectedAnswer(libdns.TXT{Name: name, Text: "a"}, "example.com.")
+		if err != nil {
+			t.Fatalf("newExpectedAnswer(%q) failed => %v", name, err)
+		}
+		if e.name != expected {
+			t.Fatalf("newExpectedAnswer(%q).name != %q => %q", name, expected, e.name)
+		}
+	}
+
+	if _, err := newExpectedAnswer(libdns.TXT{Name: "www.example.net.", Text: "a"}, "example.com"); err == nil {
+		t.Fatal("expected error for a name outside the zone")
+	}
+}
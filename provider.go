@@ -1,12 +1,12 @@
-// Package libdnstemplate implements a DNS record management client compatible
+// Package domainnameshop implements a DNS record management client compatible
 // with the libdns interfaces for Domainnameshop.
 package domainnameshop
 
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
+	"time"
 
 	"github.com/libdns/libdns"
 )
@@ -17,34 +17,100 @@ type Provider struct {
 	APIToken  string `json:"api_token"`
 	APISecret string `json:"api_secret"`
 
-	zones   map[string]dsZone
-	zonesMu sync.Mutex
+	// Credentials, if set, supplies the credentials instead of APIToken and APISecret.
+	Credentials CredentialsProvider `json:"-"`
+
+	// BaseURL of the API, https://api.domeneshop.no/v0 if empty.
+	BaseURL string `json:"base_url,omitempty"`
+	// DefaultTTL is used for records without TTL, 2 minutes if zero. The API
+	// requires TTLs to be whole minutes.
+	DefaultTTL time.Duration `json:"default_ttl,omitempty"`
+
+	// Hooks are called around every record create, update and delete.
+	Hooks []MutationHook `json:"-"`
+
+	// Tracer, if set, traces the public methods and the API requests they make.
+	Tracer Tracer `json:"-"`
+
+	// Metrics receives measurements of API requests and caches, DefaultMetrics if not set.
+	Metrics Metrics `json:"-"`
+
+	// ChallengeLedger, if set, records the ACME challenge records created by
+	// PresentChallenge for SweepChallenges.
+	ChallengeLedger *ChallengeLedger `json:"-"`
+
+	// MaxRetries is how many times a request is sent again when rate limited or on a
+	// server error. Zero doesn't retry.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	zones map[string]Domain
+	// zonesComplete is set once zones holds all domains of the account
+	zonesComplete bool
+	zonesMu       sync.Mutex
 
 	knownRecords   map[string][]dsDNSRecord
 	knownRecordsMu sync.Mutex
+
+	// IDs of the ACME challenge records created by PresentChallenge
+	challenges   map[challengeKey][]int
+	challengesMu sync.Mutex
 }
 
 // GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	zoneinfo, err := p.getAllDomainRecords(ctx, p.APIToken, p.APISecret, zone)
+func (p *Provider) GetRecords(ctx context.Context, zone string) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "GetRecords", Attribute{AttrZone, zone})
+	defer func() { endSpan(span, err) }()
+
+	if zone == "" {
+		return nil, fmt.Errorf("zone is required to list records")
+	}
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneinfo, err := p.getAllDomainRecords(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
 	if err != nil {
 		return nil, err
 	}
 	recs := make([]libdns.Record, 0, len(zoneinfo))
 	for _, rec := range zoneinfo {
-		libdnsRec, err := rec.libdnsRecord()
+		if wantsUnicode(zone) {
+			rec.Host = toUnicodeName(rec.Host)
+		}
+		libdnsRec, err := rec.libdnsRecordIn(domain.ID)
 		if err != nil {
 			return nil, fmt.Errorf("parsing Domainnameshop DNS record %+v: %v", rec, err)
 		}
 		recs = append(recs, libdnsRec)
 	}
-	log.Printf("GOT RECORDS: %#v", recs)
 
 	return recs, nil
 }
 
 // AppendRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "AppendRecords", Attribute{AttrZone, zone}, Attribute{AttrRecordCount, len(records)})
+	defer func() { endSpan(span, err) }()
+
+	if zone == "" {
+		return p.forEachZone(ctx, records, p.AppendRecords)
+	}
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
 
 	var created []libdns.Record
 	for _, rec := range records {
@@ -53,12 +119,15 @@ func (p *Provider) AppendRecords(ctx context.Context, zone string, records []lib
 			return nil, err
 		}
 
-		result, err := p.createDNSRecord(ctx, p.APIToken, p.APISecret, zone, dsrr)
+		result, err := p.createDNSRecord(ctx, token, secret, zone, dsrr)
 		if err != nil {
 			return nil, err
 		}
+		if wantsUnicode(zone, dsrr.Host) {
+			result.Host = toUnicodeName(result.Host)
+		}
 
-		libdnsRec, err := result.libdnsRecord()
+		libdnsRec, err := result.libdnsRecordIn(domain.ID)
 		if err != nil {
 			return nil, fmt.Errorf("parsing Domainnameshop DNS record %+v: %v", rec, err)
 		}
@@ -69,16 +138,36 @@ func (p *Provider) AppendRecords(ctx context.Context, zone string, records []lib
 	return created, nil
 }
 
-// DeleteRecords deletes the records from the zone.
-func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// DeleteRecords deletes the records from the zone. Records returned by the Provider
+// are deleted by their ID, others by name and data. Records of types returned as
+// libdns.RR, like TLSA and DS, can't carry an ID, so of several with the same name
+// and data any one may be deleted.
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "DeleteRecords", Attribute{AttrZone, zone}, Attribute{AttrRecordCount, len(records)})
+	defer func() { endSpan(span, err) }()
+
+	if zone == "" {
+		return p.forEachZone(ctx, records, p.DeleteRecords)
+	}
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, record := range records {
 		dsrr, converr := libdnsRecordTodsDNSRecord(record)
 		if converr != nil {
 			return nil, converr
 		}
+		dsrr.ID = recordID(record, domain.ID)
 
-		err := p.deleteDNSRecord(ctx, p.APIToken, p.APISecret, zone, dsrr)
+		err := p.deleteDNSRecord(ctx, token, secret, zone, dsrr)
 		if err != nil {
 			return nil, err
 		}
@@ -88,35 +177,128 @@ func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []lib
 }
 
 // SetRecords sets the records in the zone, either by updating existing records
-// or creating new ones. It returns the updated records.
-func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// or creating new ones. Records returned by the Provider update the record with
+// their ID. Other records update a record with the same name and type, preferably
+// one with the same data, and are only created if there is none. Records of types
+// returned as libdns.RR, like TLSA and DS, can't carry an ID and are always
+// matched this way. It returns the updated records.
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "SetRecords", Attribute{AttrZone, zone}, Attribute{AttrRecordCount, len(records)})
+	defer func() { endSpan(span, err) }()
+
+	if zone == "" {
+		return p.forEachZone(ctx, records, p.SetRecords)
+	}
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
 	var appendedRecords []dsDNSRecord
+	claimed := make(map[int]bool)
 	for _, record := range records {
 		dsrr, converr := libdnsRecordTodsDNSRecord(record)
 		if converr != nil {
 			return nil, converr
 		}
+		dsrr.ID = recordID(record, domain.ID)
+		if dsrr.ID == 0 {
+			existing, err := p.findRecordToSet(ctx, token, secret, zone, dsrr, claimed)
+			if err != nil {
+				return nil, err
+			}
+			dsrr.ID = existing.ID
+		}
+		if dsrr.ID != 0 {
+			claimed[dsrr.ID] = true
+		}
 
-		newRecord, err := p.createOrUpdateDNSRecord(ctx, p.APIToken, p.APISecret, zone, dsrr)
+		newRecord, err := p.createOrUpdateDNSRecord(ctx, token, secret, zone, dsrr)
 		if err != nil {
 			return nil, err
 		}
+		if wantsUnicode(zone, dsrr.Host) {
+			newRecord.Host = toUnicodeName(newRecord.Host)
+		}
 		appendedRecords = append(appendedRecords, newRecord)
 	}
 
 	recs := make([]libdns.Record, 0, len(appendedRecords))
 	for _, rec := range appendedRecords {
-		libdnsRec, err := rec.libdnsRecord()
+		libdnsRec, err := rec.libdnsRecordIn(domain.ID)
 		if err != nil {
 			return nil, fmt.Errorf("parsing Domainnameshop DNS record %+v: %v", rec, err)
 		}
 		recs = append(recs, libdnsRec)
 	}
-	log.Printf("GOT RECORDS: %#v", recs)
 
 	return recs, nil
 }
 
+// ListDomains lists the domains in the account. If filter is not empty only
+// domains whose name contains filter are returned, in Unicode form if filter is.
+func (p *Provider) ListDomains(ctx context.Context, filter string) (_ []Domain, err error) {
+	ctx, span := p.startSpan(ctx, "ListDomains")
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := p.listDomains(ctx, token, secret, zoneKey(filter))
+	if err != nil {
+		return nil, err
+	}
+	p.cacheDomains(domains, filter == "")
+
+	if wantsUnicode(filter) {
+		for i := range domains {
+			domains[i].Name = toUnicodeName(domains[i].Name)
+		}
+	}
+	return domains, nil
+}
+
+// GetDomain returns the domain with the given name, in the form of name.
+func (p *Provider) GetDomain(ctx context.Context, name string) (_ Domain, err error) {
+	ctx, span := p.startSpan(ctx, "GetDomain", Attribute{AttrZone, name})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return Domain{}, err
+	}
+
+	domain, err := p.getDomainInfo(ctx, token, secret, name)
+	if err != nil {
+		return Domain{}, err
+	}
+	if wantsUnicode(name) {
+		domain.Name = toUnicodeName(domain.Name)
+	}
+	return domain, nil
+}
+
+// ListForwards lists the HTTP forwards of the zone.
+func (p *Provider) ListForwards(ctx context.Context, zone string) (_ []Forward, err error) {
+	ctx, span := p.startSpan(ctx, "ListForwards", Attribute{AttrZone, zone})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.getForwards(ctx, token, secret, zone)
+}
+
 // Interface guards
 var (
 	_ libdns.RecordGetter   = (*Provider)(nil)
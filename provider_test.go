@@ -1,3 +1,8 @@
+//go:build integration
+
+// The tests in this file run against the live API and only build with the integration tag,
+// so the other tests of the package run without credentials.
+
 package domainnameshop_test
 
 import (
@@ -68,7 +73,7 @@ func TestMain(m *testing.M) {
 		fmt.Println(`Please notice that this test runs agains the public Domainname.shop DNS Api, so you sould
 never run the test with a zone, used in production.
 To run this test, you have to specify 'LIBDNS_DOMAINNAMESHOP_TEST_TOKEN', 'LIBDNS_DOMAINNAMESHOP_TEST_SECRET' and 'LIBDNS_DOMAINNAMESHOP_TEST_ZONE'.
-Example: "LIBDNS_HETZNER_TEST_TOKEN="123" LIBDNS_DOMAINNAMESHOP_TEST_SECRET="123" LIBDNS_HETZNER_TEST_ZONE="my-domain.com" go test ./... -v`)
+Example: "LIBDNS_DOMAINNAMESHOP_TEST_TOKEN="123" LIBDNS_DOMAINNAMESHOP_TEST_SECRET="123" LIBDNS_DOMAINNAMESHOP_TEST_ZONE="my-domain.com" go test -tags integration ./... -v`)
 		os.Exit(1)
 	}
 	os.Exit(m.Run())
@@ -266,3 +271,36 @@ func Test_SetRecords(t *testing.T) {
 		t.Fatalf(`records[0].Value != "new_value" => %s != "new_value"`, test2.Data)
 	}
 }
+
+func Test_ListDomains(t *testing.T) {
+	p := &domainnameshop.Provider{
+		APIToken:  envToken,
+		APISecret: envSecret,
+	}
+
+	domains, err := p.ListDomains(context.TODO(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found *domainnameshop.Domain
+	for i, d := range domains {
+		if d.Name == strings.TrimSuffix(envZone, ".") {
+			found = &domains[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("Domain not found => %s", envZone)
+	}
+	if found.ExpiryDate.IsZero() {
+		t.Fatalf("ExpiryDate not parsed for %s", found.Name)
+	}
+
+	domain, err := p.GetDomain(context.TODO(), envZone+".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain.ID != found.ID {
+		t.Fatalf("domain.ID != found.ID => %d != %d", domain.ID, found.ID)
+	}
+}
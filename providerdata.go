@@ -0,0 +1,182 @@
+package domainnameshop
+
+import "github.com/libdns/libdns"
+
+// RecordInfo is the ProviderData of the records returned by the Provider, identifying the
+// record in the API. SetRecords and DeleteRecords use it to update or delete exactly that
+// record, instead of one with the same name and data. Records of types libdns has no struct
+// for, like TLSA and DS, are returned as libdns.RR, which can't carry it.
+type RecordInfo struct {
+	// ID of the record.
+	ID int `json:"id"`
+	// DomainID is the ID of the domain the record is in.
+	DomainID int `json:"domain_id"`
+}
+
+// RecordInfoOf returns the RecordInfo of a record returned by the Provider.
+func RecordInfoOf(rec libdns.Record) (RecordInfo, bool) {
+	info, ok := providerData(rec).(RecordInfo)
+	return info, ok
+}
+
+// recordID returns the ID in the RecordInfo of rec if it's a record of the domain, or else 0.
+func recordID(rec libdns.Record, domainID int) int {
+	if info, ok := RecordInfoOf(rec); ok && info.DomainID == domainID {
+		return info.ID
+	}
+	return 0
+}
+
+// libdnsRecordIn converts r, a record of the domain, and sets its RecordInfo.
+func (r dsDNSRecord) libdnsRecordIn(domainID int) (libdns.Record, error) {
+	rec, err := r.libdnsRecord()
+	if err != nil || r.ID == 0 {
+		return rec, err
+	}
+	return withProviderData(rec, RecordInfo{ID: r.ID, DomainID: domainID}), nil
+}
+
+// valueRecord returns the record rec points to, or rec if it isn't a pointer.
+func valueRecord(rec libdns.Record) libdns.Record {
+	switch r := rec.(type) {
+	case *libdns.Address:
+		if r != nil {
+			return *r
+		}
+	case *libdns.CAA:
+		if r != nil {
+			return *r
+		}
+	case *libdns.CNAME:
+		if r != nil {
+			return *r
+		}
+	case *libdns.MX:
+		if r != nil {
+			return *r
+		}
+	case *libdns.NS:
+		if r != nil {
+			return *r
+		}
+	case *libdns.RR:
+		if r != nil {
+			return *r
+		}
+	case *libdns.SRV:
+		if r != nil {
+			return *r
+		}
+	case *libdns.ServiceBinding:
+		if r != nil {
+			return *r
+		}
+	case *libdns.TXT:
+		if r != nil {
+			return *r
+		}
+	}
+	return rec
+}
+
+func providerData(rec libdns.Record) any {
+	switch r := valueRecord(rec).(type) {
+	case libdns.Address:
+		return r.ProviderData
+	case libdns.CAA:
+		return r.ProviderData
+	case libdns.CNAME:
+		return r.ProviderData
+	case libdns.MX:
+		return r.ProviderData
+	case libdns.NS:
+		return r.ProviderData
+	case libdns.SRV:
+		return r.ProviderData
+	case libdns.ServiceBinding:
+		return r.ProviderData
+	case libdns.TXT:
+		return r.ProviderData
+	}
+	return nil
+}
+
+// withProviderData returns rec with its ProviderData set to data, if its type has one.
+// Pointers are returned as pointers to a copy, leaving the record they point to alone.
+func withProviderData(rec libdns.Record, data any) libdns.Record {
+	switch r := rec.(type) {
+	case libdns.Address:
+		r.ProviderData = data
+		return r
+	case libdns.CAA:
+		r.ProviderData = data
+		return r
+	case libdns.CNAME:
+		r.ProviderData = data
+		return r
+	case libdns.MX:
+		r.ProviderData = data
+		return r
+	case libdns.NS:
+		r.ProviderData = data
+		return r
+	case libdns.SRV:
+		r.ProviderData = data
+		return r
+	case libdns.ServiceBinding:
+		r.ProviderData = data
+		return r
+	case libdns.TXT:
+		r.ProviderData = data
+		return r
+	case *libdns.Address:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.CAA:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.CNAME:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.MX:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.NS:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.SRV:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.ServiceBinding:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	case *libdns.TXT:
+		if r != nil {
+			c := *r
+			c.ProviderData = data
+			return &c
+		}
+	}
+	return rec
+}
@@ -0,0 +1,158 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_RecordInfo(t *testing.T) {
+	var changes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/domains":
+			json.NewEncoder(w).Encode([]Domain{{ID: 1, Name: "example.com"}})
+		case r.Method == "GET" && r.URL.Path == "/domains/1/dns":
+			// Two records with the same content
+			json.NewEncoder(w).Encode([]dsDNSRecord{
+				{ID: 10, Host: "www", Type: "TXT", Data: "same", TTL: 3600},
+				{ID: 11, Host: "www", Type: "TXT", Data: "same", TTL: 3600},
+				{ID: 12, Host: "www", Type: "TLSA", Data: "abcdef", Usage: "3", Selector: "1", DType: "1", TTL: 3600},
+			})
+		default:
+			changes = append(changes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	p := &Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}
+
+	records, err := p.GetRecords(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("GetRecords failed => %v", err)
+	}
+	if info := providerData(records[1]); info != (RecordInfo{ID: 11, DomainID: 1}) {
+		t.Fatalf("RecordInfo of the second record => %+v", info)
+	}
+	if _, ok := records[2].(libdns.RR); !ok {
+		t.Fatalf("TLSA record not generic => %T", records[2])
+	}
+
+	// The second record is deleted, not the first one with the same content
+	if _, err := p.DeleteRecords(context.Background(), "example.com", records[1:2]); err != nil {
+		t.Fatalf("DeleteRecords failed => %v", err)
+	}
+	if len(changes) != 1 || changes[0] != "DELETE /domains/1/dns/11" {
+		t.Fatalf("unexpected requests => %v", changes)
+	}
+
+	// Without ProviderData the content is matched
+	changes = nil
+	if _, err := p.DeleteRecords(context.Background(), "example.com", []libdns.Record{libdns.TXT{Name: "www", Text: "same"}}); err != nil {
+		t.Fatalf("DeleteRecords failed => %v", err)
+	}
+	if len(changes) != 1 || changes[0] != "DELETE /domains/1/dns/10" {
+		t.Fatalf("unexpected requests => %v", changes)
+	}
+
+	// A record of another domain isn't updated by its ID, but like a record without
+	// ProviderData updates another record with the same name and type
+	if records, err = p.GetRecords(context.Background(), "example.com"); err != nil {
+		t.Fatalf("GetRecords failed => %v", err)
+	}
+	changes = nil
+	updated := records[1].(libdns.TXT)
+	updated.Text = "changed"
+	other := updated
+	other.ProviderData = RecordInfo{ID: 11, DomainID: 2}
+	if _, err := p.SetRecords(context.Background(), "example.com", []libdns.Record{updated, other}); err != nil {
+		t.Fatalf("SetRecords failed => %v", err)
+	}
+	if len(changes) != 2 || changes[0] != "PUT /domains/1/dns/11" || changes[1] != "PUT /domains/1/dns/10" {
+		t.Fatalf("unexpected requests => %v", changes)
+	}
+
+	// Only records without a match of the same name and type are created
+	changes = nil
+	if _, err := p.SetRecords(context.Background(), "example.com", []libdns.Record{libdns.TXT{Name: "new", Text: "new"}}); err != nil {
+		t.Fatalf("SetRecords failed => %v", err)
+	}
+	if len(changes) != 1 || changes[0] != "POST /domains/1/dns" {
+		t.Fatalf("unexpected requests => %v", changes)
+	}
+
+	// TLSA records have no ProviderData, so they're matched by name and type
+	// when set, and by name and data when deleted
+	changes = nil
+	tlsa := records[2].(libdns.RR)
+	tlsa.Data = "3 1 1 fedcba"
+	if _, err := p.SetRecords(context.Background(), "example.com", []libdns.Record{tlsa}); err != nil {
+		t.Fatalf("SetRecords failed => %v", err)
+	}
+	if _, err := p.DeleteRecords(context.Background(), "example.com", []libdns.Record{tlsa}); err != nil {
+		t.Fatalf("DeleteRecords failed => %v", err)
+	}
+	if len(changes) != 2 || changes[0] != "PUT /domains/1/dns/12" || changes[1] != "DELETE /domains/1/dns/12" {
+		t.Fatalf("unexpected requests => %v", changes)
+	}
+}
+
+func Test_providerDataPointers(t *testing.T) {
+	info := RecordInfo{ID: 10, DomainID: 1}
+	txt := &libdns.TXT{Name: "www", Text: "text"}
+
+	rec := withProviderData(txt, info)
+	if txt.ProviderData != nil {
+		t.Fatalf("record pointed to changed => %+v", txt)
+	}
+	if providerData(rec) != info {
+		t.Fatalf("providerData(%+v) != %+v", rec, info)
+	}
+	if recordID(rec, 1) != 10 || recordID(rec, 2) != 0 {
+		t.Fatalf("unexpected record ID of %+v", rec)
+	}
+
+	var nilTXT *libdns.TXT
+	if providerData(nilTXT) != nil || withProviderData(nilTXT, info) != libdns.Record(nilTXT) {
+		t.Fatal("nil pointer not left alone")
+	}
+}
+
+func Test_SetRecordsAfterAppend(t *testing.T) {
+	var changes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/domains":
+			json.NewEncoder(w).Encode([]Domain{{ID: 1, Name: "example.com"}})
+		case r.Method == "GET" && r.URL.Path == "/domains/1/dns":
+			json.NewEncoder(w).Encode([]dsDNSRecord{{ID: 10, Host: "www", Type: "A", Data: "192.0.2.1", TTL: 3600}})
+		case r.Method == "POST":
+			changes = append(changes, r.Method+" "+r.URL.Path)
+			json.NewEncoder(w).Encode(dsDNSRecord{ID: 20})
+		default:
+			changes = append(changes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	p := &Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}
+	ctx := context.Background()
+
+	if _, err := p.GetRecords(ctx, "example.com"); err != nil {
+		t.Fatalf("GetRecords failed => %v", err)
+	}
+	if _, err := p.AppendRecords(ctx, "example.com", []libdns.Record{libdns.TXT{Name: "foo", Text: "a"}}); err != nil {
+		t.Fatalf("AppendRecords failed => %v", err)
+	}
+	// The appended record is known, so it's updated instead of created again
+	if _, err := p.SetRecords(ctx, "example.com", []libdns.Record{libdns.TXT{Name: "foo", Text: "b"}}); err != nil {
+		t.Fatalf("SetRecords failed => %v", err)
+	}
+	if len(changes) != 2 || changes[0] != "POST /domains/1/dns" || changes[1] != "PUT /domains/1/dns/20" {
+		t.Fatalf("unexpected requests => %v", changes)
+	}
+}
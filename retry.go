@@ -0,0 +1,70 @@
+package domainnameshop
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryWait is the longest wait between retries without a Retry-After header.
+const maxRetryWait = 30 * time.Second
+
+// sendWithRetries sends request, and sends it again up to p.MaxRetries times when rate
+// limited or, unless it's a POST that may have been applied, on server errors. It returns
+// the last response. The number of retries is recorded on span.
+func (p *Provider) sendWithRetries(ctx context.Context, span Span, endpoint string, request *http.Request) (*http.Response, error) {
+	client := &http.Client{}
+	for retries := 0; ; retries++ {
+		if retries > 0 {
+			span.SetAttributes(Attribute{AttrHTTPRetries, retries})
+			if request.GetBody != nil {
+				body, err := request.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				request.Body = body
+			}
+		}
+
+		start := time.Now()
+		response, err := client.Do(request)
+		if err != nil {
+			p.metrics().RequestDone(endpoint, 0, time.Since(start))
+			return nil, err
+		}
+		p.metrics().RequestDone(endpoint, response.StatusCode, time.Since(start))
+		span.SetAttributes(Attribute{AttrHTTPStatus, response.StatusCode})
+
+		if retries >= p.MaxRetries || !retryable(request.Method, response.StatusCode) {
+			return response, nil
+		}
+		wait := retryAfter(response, retries)
+		p.metrics().RequestRetried(endpoint, wait, response.StatusCode == http.StatusTooManyRequests)
+		response.Body.Close()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether a request that got status can be sent again.
+func retryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	return status >= 500 && method != "POST"
+}
+
+// retryAfter is the wait before sending a request again, from the Retry-After header
+// or else doubling from one second for every retry.
+func retryAfter(response *http.Response, retries int) time.Duration {
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return min(time.Second<<retries, maxRetryWait)
+}
@@ -0,0 +1,106 @@
+package domainnameshop
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_retryable(t *testing.T) {
+	for _, c := range []struct {
+		method   string
+		status   int
+		expected bool
+	}{
+		{"GET", http.StatusTooManyRequests, true},
+		{"POST", http.StatusTooManyRequests, true},
+		{"GET", http.StatusServiceUnavailable, true},
+		{"PUT", http.StatusInternalServerError, true},
+		{"DELETE", http.StatusBadGateway, true},
+		{"POST", http.StatusServiceUnavailable, false},
+		{"GET", http.StatusNotFound, false},
+		{"GET", http.StatusOK, false},
+	} {
+		if got := retryable(c.method, c.status); got != c.expected {
+			t.Fatalf("retryable(%s, %d) != %v", c.method, c.status, c.expected)
+		}
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	response := &http.Response{Header: http.Header{}}
+	for retries, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if wait := retryAfter(response, retries); wait != expected {
+			t.Fatalf("retryAfter(%d) != %v => %v", retries, expected, wait)
+		}
+	}
+	if wait := retryAfter(response, 10); wait != maxRetryWait {
+		t.Fatalf("retryAfter(10) != %v => %v", maxRetryWait, wait)
+	}
+
+	response.Header.Set("Retry-After", "7")
+	if wait := retryAfter(response, 3); wait != 7*time.Second {
+		t.Fatalf("Retry-After header not used => %v", wait)
+	}
+	response.Header.Set("Retry-After", "Wed, 21 Oct 2015 07:28:00 GMT")
+	if wait := retryAfter(response, 0); wait != time.Second {
+		t.Fatalf("unparsable Retry-After header not ignored => %v", wait)
+	}
+}
+
+func Test_doRequestRetries(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+string(body))
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	for _, c := range []struct {
+		method     string
+		maxRetries int
+		expected   int
+	}{
+		{"PUT", 2, 3},
+		{"POST", 2, 1},
+		{"GET", 0, 1},
+	} {
+		requests = nil
+		p := &Provider{MaxRetries: c.maxRetries, Metrics: NewExpvarMetrics()}
+		req, _ := http.NewRequest(c.method, server.URL+"/domains/1/dns/2", strings.NewReader("body"))
+		err := p.doRequest("token", "secret", req, "/domains/{id}/dns/{recordId}", nil)
+		if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected APIError with status 503 => %v", c.method, err)
+		}
+		if len(requests) != c.expected {
+			t.Fatalf("%s: %d requests != %d", c.method, len(requests), c.expected)
+		}
+		for _, r := range requests {
+			if r != c.method+" body" {
+				t.Fatalf("%s: body not sent again => %q", c.method, r)
+			}
+		}
+	}
+}
+
+func Test_doRequestRetryCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	p := &Provider{MaxRetries: 1, Metrics: NewExpvarMetrics()}
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/domains", nil)
+	if err := p.doRequest("token", "secret", req, "/domains", nil); err != context.DeadlineExceeded {
+		t.Fatalf("wait not canceled => %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotVersion is the version of the Snapshot format written by this package.
+const SnapshotVersion = 1
+
+// Snapshot is the state of a zone, including Domeneshop record IDs and HTTP forwards.
+// It can be serialized to JSON or YAML and given to Restore.
+type Snapshot struct {
+	Version  int              `json:"version" yaml:"version"`
+	Zone     string           `json:"zone" yaml:"zone"`
+	DomainID int              `json:"domain_id" yaml:"domain_id"`
+	TakenAt  time.Time        `json:"taken_at" yaml:"taken_at"`
+	Records  []SnapshotRecord `json:"records" yaml:"records"`
+	Forwards []Forward        `json:"forwards" yaml:"forwards"`
+}
+
+// SnapshotRecord is a DNS record as stored by the Domeneshop API.
+// Type specific fields are only set for the record types using them.
+type SnapshotRecord struct {
+	ID       int    `json:"id" yaml:"id"`
+	Host     string `json:"host" yaml:"host"`
+	Type     string `json:"type" yaml:"type"`
+	TTL      int    `json:"ttl" yaml:"ttl"`
+	Data     string `json:"data" yaml:"data"`
+	Priority string `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Weight   string `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Port     string `json:"port,omitempty" yaml:"port,omitempty"`
+	Flags    string `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Tag      string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Usage    string `json:"usage,omitempty" yaml:"usage,omitempty"`
+	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	DType    string `json:"dtype,omitempty" yaml:"dtype,omitempty"`
+	Alg      string `json:"alg,omitempty" yaml:"alg,omitempty"`
+	Digest   string `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+func snapshotRecord(r dsDNSRecord) SnapshotRecord {
+	return SnapshotRecord{
+		ID:       r.ID,
+		Host:     r.Host,
+		Type:     r.Type,
+		TTL:      r.TTL,
+		Data:     r.Data,
+		Priority: r.Priority,
+		Weight:   r.Weight,
+		Port:     r.Port,
+		Flags:    string(r.Flags),
+		Tag:      string(r.Tag),
+		Usage:    string(r.Usage),
+		Selector: string(r.Selector),
+		DType:    string(r.DType),
+		Alg:      string(r.Alg),
+		Digest:   string(r.Digest),
+	}
+}
+
+func (r SnapshotRecord) dsDNSRecord() dsDNSRecord {
+	return dsDNSRecord{
+		ID:       r.ID,
+		Host:     r.Host,
+		Type:     r.Type,
+		TTL:      r.TTL,
+		Data:     r.Data,
+		Priority: r.Priority,
+		Weight:   r.Weight,
+		Port:     r.Port,
+		Flags:    dsField(r.Flags),
+		Tag:      dsField(r.Tag),
+		Usage:    dsField(r.Usage),
+		Selector: dsField(r.Selector),
+		DType:    dsField(r.DType),
+		Alg:      dsField(r.Alg),
+		Digest:   dsField(r.Digest),
+	}
+}
+
+// Snapshot captures the current records and HTTP forwards of the zone.
+func (p *Provider) Snapshot(ctx context.Context, zone string) (_ *Snapshot, err error) {
+	ctx, span := p.startSpan(ctx, "Snapshot", Attribute{AttrZone, zone})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := p.getDomainInfo(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := p.getAllDomainRecords(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	forwards, err := p.getForwards(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Version:  SnapshotVersion,
+		Zone:     removeFQDNTrailingDot(zone),
+		DomainID: domain.ID,
+		TakenAt:  time.Now().UTC(),
+		Records:  make([]SnapshotRecord, 0, len(records)),
+		Forwards: forwards,
+	}
+	if snapshot.Forwards == nil {
+		snapshot.Forwards = []Forward{}
+	}
+	for _, r := range records {
+		snapshot.Records = append(snapshot.Records, snapshotRecord(r))
+	}
+
+	return snapshot, nil
+}
+
+// RestoreResult lists the changes Restore made.
+type RestoreResult struct {
+	ImportResult
+	CreatedForwards []Forward
+	UpdatedForwards []Forward
+	DeletedForwards []Forward
+}
+
+// Restore brings the zone of the snapshot back to the state it was in, with as few changes as possible.
+// Records still present keep their IDs, records that were changed are updated in place and
+// records created after the snapshot are deleted. HTTP forwards are restored the same way.
+func (p *Provider) Restore(ctx context.Context, snapshot *Snapshot) (_ RestoreResult, err error) {
+	ctx, span := p.startSpan(ctx, "Restore", Attribute{AttrZone, snapshot.Zone})
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	if snapshot.Version > SnapshotVersion {
+		return RestoreResult{}, fmt.Errorf("snapshot version %d is newer than supported version %d", snapshot.Version, SnapshotVersion)
+	}
+
+	desired := make([]dsDNSRecord, 0, len(snapshot.Records))
+	for _, r := range snapshot.Records {
+		desired = append(desired, r.dsDNSRecord())
+	}
+
+	live, err := p.getAllDomainRecords(ctx, token, secret, snapshot.Zone)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	var result RestoreResult
+	applied, err := p.applyPlan(ctx, token, secret, snapshot.Zone, planChanges(live, desired, true))
+	var convErr error
+	result.ImportResult, convErr = importResult(applied)
+	if err != nil {
+		return result, err
+	}
+	if convErr != nil {
+		return result, convErr
+	}
+
+	liveForwards, err := p.getForwards(ctx, token, secret, snapshot.Zone)
+	if err != nil {
+		return result, err
+	}
+	wanted := make(map[string]Forward, len(snapshot.Forwards))
+	for _, f := range snapshot.Forwards {
+		wanted[f.Host] = f
+	}
+	for _, f := range liveForwards {
+		want, ok := wanted[f.Host]
+		switch {
+		case !ok:
+			if err := p.deleteForward(ctx, token, secret, snapshot.Zone, f); err != nil {
+				return result, err
+			}
+			result.DeletedForwards = append(result.DeletedForwards, f)
+		case want != f:
+			if err := p.updateForward(ctx, token, secret, snapshot.Zone, want); err != nil {
+				return result, err
+			}
+			result.UpdatedForwards = append(result.UpdatedForwards, want)
+		}
+		delete(wanted, f.Host)
+	}
+	for _, f := range snapshot.Forwards {
+		if _, ok := wanted[f.Host]; !ok {
+			continue
+		}
+		if err := p.createForward(ctx, token, secret, snapshot.Zone, f); err != nil {
+			return result, err
+		}
+		result.CreatedForwards = append(result.CreatedForwards, f)
+	}
+
+	return result, nil
+}
+
+// BackupStore keeps timestamped JSON snapshots in a directory per zone.
+type BackupStore struct {
+	Dir string
+	// MaxCount is the number of snapshots kept per zone, 0 keeps all.
+	MaxCount int
+	// MaxAge is how long snapshots are kept, 0 keeps them forever.
+	// The newest snapshot of a zone is always kept.
+	MaxAge time.Duration
+}
+
+// Backup is a snapshot stored in a BackupStore.
+type Backup struct {
+	Path    string
+	Zone    string
+	TakenAt time.Time
+}
+
+// backupTimeLayout names the snapshot files. Files named by the older layout without
+// nanoseconds are still listed.
+const (
+	backupTimeLayout    = "20060102T150405.000000000Z"
+	oldBackupTimeLayout = "20060102T150405Z"
+)
+
+// zoneDir returns the directory of the snapshots of zone.
+func (s *BackupStore) zoneDir(zone string) (string, string, error) {
+	key := zoneKey(zone)
+	if key == "" || key == "." || strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+		return "", "", fmt.Errorf("invalid zone name %q", zone)
+	}
+	return filepath.Join(s.Dir, key), key, nil
+}
+
+// Save writes the snapshot to the store and removes snapshots exceeding the retention limits.
+// It returns the path of the written file. Saving a second snapshot of a zone taken at the
+// same time is an error.
+func (s *BackupStore) Save(snapshot *Snapshot) (string, error) {
+	dir, zone, err := s.zoneDir(snapshot.Zone)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, snapshot.TakenAt.UTC().Format(backupTimeLayout)+".json")
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("snapshot %s already exists", path)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+
+	return path, s.prune(zone, time.Now())
+}
+
+// List returns the snapshots stored for the zone, newest first.
+func (s *BackupStore) List(zone string) ([]Backup, error) {
+	dir, zone, err := s.zoneDir(zone)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		takenAt, err := time.Parse(backupTimeLayout, name)
+		if err != nil {
+			if takenAt, err = time.Parse(oldBackupTimeLayout, name); err != nil {
+				continue
+			}
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, e.Name()), Zone: zone, TakenAt: takenAt})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].TakenAt.After(backups[j].TakenAt)
+	})
+
+	return backups, nil
+}
+
+// Latest loads the newest snapshot of the zone.
+func (s *BackupStore) Latest(zone string) (*Snapshot, error) {
+	backups, err := s.List(zone)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no snapshots of %s in %s", zone, s.Dir)
+	}
+	return LoadSnapshot(backups[0].Path)
+}
+
+func (s *BackupStore) prune(zone string, now time.Time) error {
+	backups, err := s.List(zone)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range backups {
+		if i == 0 {
+			continue
+		}
+		if (s.MaxCount > 0 && i >= s.MaxCount) || (s.MaxAge > 0 && now.Sub(b.TakenAt) > s.MaxAge) {
+			if err := os.Remove(b.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by BackupStore, json.Marshal or yaml.Marshal.
+// Files ending in .yaml or .yml are read as YAML, others as JSON.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &snapshot)
+	default:
+		err = json.Unmarshal(data, &snapshot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %v", path, err)
+	}
+	if snapshot.Version == 0 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("reading snapshot %s: unsupported version %d", path, snapshot.Version)
+	}
+	return &snapshot, nil
+}
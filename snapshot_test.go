@@ -0,0 +1,191 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_BackupStore(t *testing.T) {
+	store := &BackupStore{Dir: t.TempDir(), MaxCount: 3}
+
+	start := time.Now().UTC().Add(-10 * time.Hour).Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		snapshot := &Snapshot{
+			Version: SnapshotVersion,
+			Zone:    "example.com",
+			TakenAt: start.Add(time.Duration(i) * time.Hour),
+			Records: []SnapshotRecord{{ID: i + 1, Host: "www", Type: "A", TTL: 3600, Data: "192.0.2.1"}},
+		}
+		if _, err := store.Save(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := store.List("example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("len(backups) != 3 => %d", len(backups))
+	}
+	if !backups[0].TakenAt.Equal(start.Add(4 * time.Hour)) {
+		t.Fatalf("backups[0] is not the newest => %s", backups[0].TakenAt)
+	}
+
+	latest, err := store.Latest("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Records[0].ID != 5 {
+		t.Fatalf("latest.Records[0].ID != 5 => %d", latest.Records[0].ID)
+	}
+
+	store.MaxAge = 7 * time.Hour
+	if _, err := store.Save(&Snapshot{Version: SnapshotVersion, Zone: "example.com", TakenAt: start.Add(5 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	backups, err = store.List("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("len(backups) != 2 after MaxAge => %d", len(backups))
+	}
+}
+
+func Test_BackupStoreNames(t *testing.T) {
+	store := &BackupStore{Dir: t.TempDir()}
+
+	for _, zone := range []string{"", "..", "../etc", "a/b", `a\b`, "example..com"} {
+		if _, err := store.Save(&Snapshot{Version: SnapshotVersion, Zone: zone}); err == nil {
+			t.Fatalf("zone %q not rejected", zone)
+		}
+	}
+
+	// Snapshots taken within the same second get their own files
+	takenAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for _, d := range []time.Duration{0, time.Millisecond} {
+		if _, err := store.Save(&Snapshot{Version: SnapshotVersion, Zone: "Example.com.", TakenAt: takenAt.Add(d)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Save(&Snapshot{Version: SnapshotVersion, Zone: "example.com", TakenAt: takenAt}); err == nil {
+		t.Fatal("existing snapshot overwritten")
+	}
+
+	// Files named by the older layout are still listed
+	old := filepath.Join(store.Dir, "example.com", takenAt.Add(-time.Hour).Format(oldBackupTimeLayout)+".json")
+	if err := os.WriteFile(old, []byte(`{"version":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := store.List("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 || !backups[1].TakenAt.Equal(takenAt) || backups[2].Path != old {
+		t.Fatalf("unexpected backups => %+v", backups)
+	}
+}
+
+func Test_SnapshotJSON(t *testing.T) {
+	data := `{"version":1,"zone":"example.com","domain_id":7,"taken_at":"2026-10-18T12:00:00Z",` +
+		`"records":[{"id":3,"host":"@","type":"CAA","ttl":3600,"data":"letsencrypt.org","flags":"0","tag":"issue"}],` +
+		`"forwards":[{"host":"www","frame":false,"url":"https://example.net"}]}`
+
+	var snapshot Snapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	rec := snapshot.Records[0].dsDNSRecord()
+	if rec.ID != 3 || rec.Tag != "issue" || rec.Flags != "0" {
+		t.Fatalf("unexpected record => %+v", rec)
+	}
+	if snapshotRecord(rec) != snapshot.Records[0] {
+		t.Fatalf("round trip mismatch => %+v != %+v", snapshotRecord(rec), snapshot.Records[0])
+	}
+}
+
+func Test_SnapshotYAML(t *testing.T) {
+	snapshot := &Snapshot{
+		Version:  SnapshotVersion,
+		Zone:     "example.com",
+		DomainID: 7,
+		TakenAt:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		Records:  []SnapshotRecord{{ID: 3, Host: "@", Type: "CAA", TTL: 3600, Data: "letsencrypt.org", Flags: "0", Tag: "issue"}},
+		Forwards: []Forward{{Host: "www", URL: "https://example.net"}},
+	}
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "example.com.yaml")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed => %v", err)
+	}
+	if !loaded.TakenAt.Equal(snapshot.TakenAt) || loaded.DomainID != 7 ||
+		loaded.Records[0] != snapshot.Records[0] || loaded.Forwards[0] != snapshot.Forwards[0] {
+		t.Fatalf("round trip mismatch => %+v", loaded)
+	}
+}
+
+func Test_planChangesRestore(t *testing.T) {
+	live := []dsDNSRecord{
+		{ID: 1, Host: "www", Type: "A", Data: "192.0.2.9", TTL: 3600},
+		{ID: 2, Host: "www", Type: "A", Data: "192.0.2.2", TTL: 3600},
+		{ID: 5, Host: "added", Type: "TXT", Data: "later", TTL: 3600},
+	}
+	snapshot := []dsDNSRecord{
+		{ID: 1, Host: "www", Type: "A", Data: "192.0.2.1", TTL: 3600},
+		{ID: 2, Host: "www", Type: "A", Data: "192.0.2.2", TTL: 3600},
+		{ID: 4, Host: "removed", Type: "TXT", Data: "gone", TTL: 3600},
+	}
+
+	plan := planChanges(live, snapshot, true)
+	if len(plan.update) != 1 || plan.update[0].after.ID != 1 || plan.update[0].after.Data != "192.0.2.1" {
+		t.Fatalf("expected record 1 updated => %+v", plan.update)
+	}
+	if len(plan.create) != 1 || plan.create[0].ID != 0 || plan.create[0].Host != "removed" {
+		t.Fatalf("expected create of removed without ID => %+v", plan.create)
+	}
+	if len(plan.delete) != 1 || plan.delete[0].ID != 5 {
+		t.Fatalf("expected delete of record 5 => %+v", plan.delete)
+	}
+}
+
+func Test_RestoreConversionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/domains":
+			json.NewEncoder(w).Encode([]Domain{{ID: 1, Name: "example.com"}})
+		case r.Method == "GET":
+			w.Write([]byte("[]"))
+		default:
+			w.Write([]byte(`{"id":5}`))
+		}
+	}))
+	defer server.Close()
+	p := &Provider{APIToken: "token", APISecret: "secret", BaseURL: server.URL}
+
+	// The record is created, but can't be converted for the result
+	snapshot := &Snapshot{
+		Version: SnapshotVersion,
+		Zone:    "example.com",
+		Records: []SnapshotRecord{{Host: "@", Type: "MX", TTL: 3600, Priority: "high", Data: "mail.example.com"}},
+	}
+	if _, err := p.Restore(context.Background(), snapshot); err == nil {
+		t.Fatal("expected error for the unparsable record")
+	}
+}
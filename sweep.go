@@ -0,0 +1,266 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LedgerEntry is an ACME challenge record created by PresentChallenge.
+type LedgerEntry struct {
+	Zone      string    `json:"zone"`
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	RecordID  int       `json:"record_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChallengeLedger is a JSON file listing the ACME challenge records a Provider created
+// and hasn't cleaned up yet, so SweepChallenges knows how old they are. The file is read
+// and written on every change, so a ledger can be shared by providers in several processes
+// as long as they don't change it at the same time.
+type ChallengeLedger struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Entries returns the entries of the ledger, which are empty if the file doesn't exist yet.
+func (l *ChallengeLedger) Entries() ([]LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.load()
+}
+
+// Add adds an entry to the ledger.
+func (l *ChallengeLedger) Add(entry LedgerEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.load()
+	if err != nil {
+		return err
+	}
+	return l.save(append(entries, entry))
+}
+
+// Remove removes the entries of the records with the given IDs in zone.
+func (l *ChallengeLedger) Remove(zone string, ids ...int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	zone = zoneKey(zone)
+	kept := entries[:0]
+	for _, e := range entries {
+		removed := false
+		for _, id := range ids {
+			if zoneKey(e.Zone) == zone && e.RecordID == id {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(entries) {
+		return nil
+	}
+	return l.save(kept)
+}
+
+func (l *ChallengeLedger) load() ([]LedgerEntry, error) {
+	data, err := os.ReadFile(l.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []LedgerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading challenge ledger %s: %v", l.Path, err)
+	}
+	return entries, nil
+}
+
+func (l *ChallengeLedger) save(entries []LedgerEntry) error {
+	if entries == nil {
+		entries = []LedgerEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o700); err != nil {
+		return err
+	}
+	tmp := l.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.Path)
+}
+
+// SweepOptions configures SweepChallenges.
+type SweepOptions struct {
+	// Zones to sweep, all zones in the account if empty.
+	Zones []string
+	// OlderThan is the age from which challenge records in the ledger are deleted.
+	OlderThan time.Duration
+	// DryRun reports what would be deleted without deleting it.
+	DryRun bool
+}
+
+// SweepAction is what SweepChallenges did with a challenge record.
+type SweepAction string
+
+const (
+	SweepDeleted     SweepAction = "deleted"
+	SweepWouldDelete SweepAction = "would-delete"
+	// SweepRecent records are in the ledger, but not older than SweepOptions.OlderThan.
+	SweepRecent SweepAction = "recent"
+	// SweepUnknown records aren't in the ledger, so their age isn't known and they're kept.
+	SweepUnknown SweepAction = "unknown"
+)
+
+// SweptRecord is a challenge record found by SweepChallenges.
+type SweptRecord struct {
+	Zone     string `json:"zone"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	RecordID int    `json:"record_id"`
+	// CreatedAt is zero for records that aren't in the ledger.
+	CreatedAt time.Time   `json:"created_at"`
+	Action    SweepAction `json:"action"`
+}
+
+// isChallengeName reports whether host, relative to its zone, is an ACME challenge name.
+func isChallengeName(host string) bool {
+	host = strings.ToLower(host)
+	return host == "_acme-challenge" || strings.HasPrefix(host, "_acme-challenge.")
+}
+
+// SweepChallenges finds the ACME challenge TXT records in the zones and deletes those the
+// ledger of the Provider lists as created longer than OlderThan ago, such as records left
+// behind by failed renewals. Records that aren't in the ledger are reported, but kept. Ledger
+// entries of records that no longer exist are removed. A zone that fails doesn't stop the
+// sweep of the others: it returns the records found in all zones, with the errors of the
+// failed zones joined.
+func (p *Provider) SweepChallenges(ctx context.Context, opts SweepOptions) (_ []SweptRecord, err error) {
+	ctx, span := p.startSpan(ctx, "SweepChallenges")
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ChallengeLedger == nil {
+		return nil, fmt.Errorf("sweeping challenges requires a ChallengeLedger")
+	}
+	entries, err := p.ChallengeLedger.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	zones := opts.Zones
+	if len(zones) == 0 {
+		domains, err := p.ListDomains(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domains {
+			zones = append(zones, d.Name)
+		}
+	}
+
+	now := time.Now()
+	var swept []SweptRecord
+	var errs []error
+	for _, zone := range zones {
+		zone = removeFQDNTrailingDot(zone)
+		found, err := p.sweepZone(ctx, token, secret, zone, entries, now, opts)
+		swept = append(swept, found...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sweeping %s: %w", zone, err))
+		}
+	}
+
+	return swept, errors.Join(errs...)
+}
+
+// sweepZone sweeps the challenge records of one zone. It returns the records found, also
+// when deleting one of them failed.
+func (p *Provider) sweepZone(ctx context.Context, token string, secret string, zone string, entries []LedgerEntry, now time.Time, opts SweepOptions) ([]SweptRecord, error) {
+	records, err := p.getAllDomainRecords(ctx, token, secret, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	found, gone := classifyChallenges(zone, records, entries, now, opts.OlderThan)
+	for i, s := range found {
+		if s.Action != SweepWouldDelete || opts.DryRun {
+			continue
+		}
+		record := dsDNSRecord{ID: s.RecordID, Host: s.Name, Type: "TXT", Data: encodeTXT(s.Value)}
+		if err := p.deleteDNSRecordByID(ctx, token, secret, zone, record); err != nil {
+			return found, err
+		}
+		found[i].Action = SweepDeleted
+		gone = append(gone, s.RecordID)
+	}
+
+	if len(gone) > 0 && !opts.DryRun {
+		if err := p.ChallengeLedger.Remove(zone, gone...); err != nil {
+			return found, err
+		}
+	}
+	return found, nil
+}
+
+// classifyChallenges returns the challenge records of zone sorted by name, with the action
+// for them, and the IDs of ledger entries of the zone whose records no longer exist.
+func classifyChallenges(zone string, records []dsDNSRecord, entries []LedgerEntry, now time.Time, olderThan time.Duration) ([]SweptRecord, []int) {
+	ledger := make(map[int]LedgerEntry)
+	for _, e := range entries {
+		if zoneKey(e.Zone) == zoneKey(zone) {
+			ledger[e.RecordID] = e
+		}
+	}
+
+	var found []SweptRecord
+	for _, r := range records {
+		if r.Type != "TXT" || !isChallengeName(r.Host) {
+			continue
+		}
+		s := SweptRecord{Zone: zone, Name: r.Host, Value: decodeTXT(r.Data), RecordID: r.ID, Action: SweepUnknown}
+		if e, ok := ledger[r.ID]; ok {
+			s.CreatedAt = e.CreatedAt
+			s.Action = SweepRecent
+			if now.Sub(e.CreatedAt) > olderThan {
+				s.Action = SweepWouldDelete
+			}
+			delete(ledger, r.ID)
+		}
+		found = append(found, s)
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+
+	gone := make([]int, 0, len(ledger))
+	for id := range ledger {
+		gone = append(gone, id)
+	}
+	sort.Ints(gone)
+	return found, gone
+}
@@ -0,0 +1,105 @@
+package domainnameshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ChallengeLedger(t *testing.T) {
+	ledger := &ChallengeLedger{Path: filepath.Join(t.TempDir(), "acme", "ledger.json")}
+
+	entries, err := ledger.Entries()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty ledger => %v, %v", entries, err)
+	}
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for id := 1; id <= 3; id++ {
+		if err := ledger.Add(LedgerEntry{Zone: "example.com", Name: "_acme-challenge", Value: "v", RecordID: id, CreatedAt: created}); err != nil {
+			t.Fatalf("Add failed => %v", err)
+		}
+	}
+	if err := ledger.Remove("example.com.", 1, 3); err != nil {
+		t.Fatalf("Remove failed => %v", err)
+	}
+	// Other zones are left alone
+	if err := ledger.Remove("example.net", 2); err != nil {
+		t.Fatalf("Remove failed => %v", err)
+	}
+
+	entries, err = ledger.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed => %v", err)
+	}
+	expected := []LedgerEntry{{Zone: "example.com", Name: "_acme-challenge", Value: "v", RecordID: 2, CreatedAt: created}}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("entries != expected => %+v != %+v", entries, expected)
+	}
+}
+
+func Test_classifyChallenges(t *testing.T) {
+	now := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	records := []dsDNSRecord{
+		{ID: 1, Host: "_acme-challenge", Type: "TXT", Data: "old"},
+		{ID: 2, Host: "_acme-challenge.www", Type: "TXT", Data: "new"},
+		{ID: 3, Host: "_acme-challenge", Type: "TXT", Data: "manual"},
+		{ID: 4, Host: "www", Type: "TXT", Data: "not a challenge"},
+		{ID: 5, Host: "_acme-challenge", Type: "CNAME", Data: "delegated.example.net"},
+	}
+	entries := []LedgerEntry{
+		{Zone: "example.com", RecordID: 1, CreatedAt: now.Add(-72 * time.Hour)},
+		{Zone: "example.com", RecordID: 2, CreatedAt: now.Add(-time.Hour)},
+		{Zone: "example.com", RecordID: 9, CreatedAt: now.Add(-72 * time.Hour)},
+		{Zone: "example.net", RecordID: 3, CreatedAt: now.Add(-72 * time.Hour)},
+	}
+
+	found, gone := classifyChallenges("example.com", records, entries, now, 24*time.Hour)
+
+	expected := []SweptRecord{
+		{Zone: "example.com", Name: "_acme-challenge", Value: "old", RecordID: 1, CreatedAt: now.Add(-72 * time.Hour), Action: SweepWouldDelete},
+		{Zone: "example.com", Name: "_acme-challenge", Value: "manual", RecordID: 3, Action: SweepUnknown},
+		{Zone: "example.com", Name: "_acme-challenge.www", Value: "new", RecordID: 2, CreatedAt: now.Add(-time.Hour), Action: SweepRecent},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("found != expected => %+v != %+v", found, expected)
+	}
+	if !reflect.DeepEqual(gone, []int{9}) {
+		t.Fatalf("gone != [9] => %v", gone)
+	}
+}
+
+func Test_SweepChallengesZoneError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/domains":
+			json.NewEncoder(w).Encode([]Domain{{ID: 1, Name: "example.com"}, {ID: 2, Name: "example.net"}, {ID: 3, Name: "example.org"}})
+		case "/domains/2/dns":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			json.NewEncoder(w).Encode([]dsDNSRecord{{ID: 10, Host: "_acme-challenge", Type: "TXT", Data: "token", TTL: 300}})
+		}
+	}))
+	defer server.Close()
+	p := &Provider{
+		APIToken:        "token",
+		APISecret:       "secret",
+		BaseURL:         server.URL,
+		ChallengeLedger: &ChallengeLedger{Path: filepath.Join(t.TempDir(), "ledger.json")},
+	}
+
+	// The zone after the failing one is still swept
+	swept, err := p.SweepChallenges(context.Background(), SweepOptions{DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "example.net") {
+		t.Fatalf("expected error for example.net => %v", err)
+	}
+	if len(swept) != 2 || swept[0].Zone != "example.com" || swept[1].Zone != "example.org" {
+		t.Fatalf("unexpected swept records => %+v", swept)
+	}
+}
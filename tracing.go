@@ -0,0 +1,89 @@
+package domainnameshop
+
+import (
+	"context"
+)
+
+// Tracer starts spans for the operations of the Provider. It's a small subset of the
+// OpenTelemetry tracing API, so this package doesn't depend on it; an adapter is a
+// few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, domainnameshop.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// Start starts a span that is a child of the span in ctx, if any.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError records a failure of the operation, it's called at most once before End.
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key-value pair describing a span. Value is a string, int or bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span attribute keys.
+const (
+	AttrZone        = "domainnameshop.zone"
+	AttrRecordCount = "domainnameshop.record_count"
+	AttrZoneCache   = "domainnameshop.zone_cache"
+	AttrRecordCache = "domainnameshop.record_cache"
+	AttrHTTPMethod  = "http.request.method"
+	AttrHTTPRoute   = "http.route"
+	AttrHTTPStatus  = "http.response.status_code"
+	AttrHTTPRetries = "http.request.resend_count"
+)
+
+// Values of AttrZoneCache and AttrRecordCache.
+const (
+	cacheHit  = "hit"
+	cacheMiss = "miss"
+)
+
+type spanKey struct{}
+
+// startSpan starts a span named after the operation, which is a noop without a Tracer.
+// The span is kept in the returned context, see spanFromContext.
+func (p *Provider) startSpan(ctx context.Context, operation string, attrs ...Attribute) (context.Context, Span) {
+	if p.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := p.Tracer.Start(ctx, "domainnameshop."+operation)
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// endSpan records err, if any, and ends the span.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// spanFromContext returns the innermost span started by the Provider, or a noop span.
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
@@ -0,0 +1,126 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &testSpan{name: name, attrs: make(map[string]any)}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func Test_doRequestTracesRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "domain": "example.com"}]`))
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+	p := &Provider{Tracer: tracer, MaxRetries: 2}
+	req, _ := http.NewRequest("GET", server.URL+"/domains", nil)
+
+	var domains []Domain
+	if err := p.doRequest("", "", req, "/domains", &domains); err != nil {
+		t.Fatalf("doRequest failed => %v", err)
+	}
+	if len(domains) != 1 || domains[0].ID != 1 {
+		t.Fatalf("unexpected domains => %+v", domains)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("len(spans) != 1 => %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "domainnameshop.GET /domains" || !span.ended || span.err != nil {
+		t.Fatalf("unexpected span => %+v", span)
+	}
+	if span.attrs[AttrHTTPRoute] != "/domains" || span.attrs[AttrHTTPStatus] != 200 || span.attrs[AttrHTTPRetries] != 2 {
+		t.Fatalf("unexpected span attributes => %v", span.attrs)
+	}
+}
+
+func Test_doRequestGivesUp(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+	p := &Provider{Tracer: tracer, MaxRetries: 1}
+
+	// POST is not retried on server errors, the record may have been created
+	req, _ := http.NewRequest("POST", server.URL+"/domains/1/dns", nil)
+	if err := p.doRequest("", "", req, "/domains/{id}/dns", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("calls != 1 => %d", calls)
+	}
+	if span := tracer.spans[0]; span.err == nil || span.attrs[AttrHTTPStatus] != http.StatusBadGateway {
+		t.Fatalf("unexpected span => %+v", span)
+	}
+}
+
+func Test_spanCacheAttributes(t *testing.T) {
+	tracer := &testTracer{}
+	p := &Provider{
+		Tracer: tracer,
+		zones:  map[string]Domain{"example.com": {ID: 1, Name: "example.com"}},
+	}
+
+	if _, err := p.GetDomain(context.Background(), "example.com."); err != nil {
+		t.Fatalf("GetDomain failed => %v", err)
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("len(spans) != 1 => %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "domainnameshop.GetDomain" || span.attrs[AttrZoneCache] != cacheHit || span.attrs[AttrZone] != "example.com." {
+		t.Fatalf("unexpected span => %+v", span)
+	}
+
+	// Without a tracer the context is left alone
+	p.Tracer = nil
+	ctx, span2 := p.startSpan(context.Background(), "GetDomain")
+	if ctx != context.Background() || spanFromContext(ctx) != (noopSpan{}) {
+		t.Fatalf("unexpected span without tracer => %+v", span2)
+	}
+	endSpan(span2, errors.New("ignored"))
+}
@@ -0,0 +1,76 @@
+package domainnameshop
+
+import "strings"
+
+// TXT data is sent to the API as the text itself, without quotes or escapes, and of any
+// length: the API splits it into character-strings of at most 255 bytes when serving the
+// record. Records created elsewhere, for instance by pasting a DKIM key in zone file form,
+// may however hold the text as quoted character-strings, like "v=DKIM1; k=rsa; " "p=MIIB...".
+// Such data is decoded and the strings joined, the way libdns treats TXT records as one
+// string. To keep this unambiguous, text that would itself be read as quoted strings is
+// sent in that form too, so it decodes to the original text.
+
+// maxCharacterString is the length limit of a <character-string>, RFC 1035 section 3.3.
+const maxCharacterString = 255
+
+// encodeTXT returns the API data of a TXT record with text.
+func encodeTXT(text string) string {
+	if _, ok := parseCharacterStrings(text); ok {
+		return quoteCharacterStrings(text)
+	}
+	return text
+}
+
+// decodeTXT returns the text of a TXT record with API data.
+func decodeTXT(data string) string {
+	if strs, ok := parseCharacterStrings(data); ok {
+		return strings.Join(strs, "")
+	}
+	return data
+}
+
+// parseCharacterStrings parses s as one or more quoted character-strings separated by
+// white space. It reports false if s is anything else.
+func parseCharacterStrings(s string) ([]string, bool) {
+	var strs []string
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case ' ', '\t':
+			i++
+			continue
+		case '"':
+		default:
+			return nil, false
+		}
+		i++
+		var b strings.Builder
+		for {
+			if i >= len(s) {
+				return nil, false
+			}
+			if s[i] == '"' {
+				i++
+				break
+			}
+			n, err := unescapeZoneChar(s[i:], &b)
+			if err != nil {
+				return nil, false
+			}
+			i += n
+		}
+		strs = append(strs, b.String())
+	}
+	return strs, len(strs) > 0
+}
+
+// quoteCharacterStrings quotes s as character-strings of at most 255 bytes each,
+// separated by spaces, as TXT records are written in presentation format.
+func quoteCharacterStrings(s string) string {
+	var strs []string
+	for len(s) > maxCharacterString {
+		strs = append(strs, quoteCharacterString(s[:maxCharacterString]))
+		s = s[maxCharacterString:]
+	}
+	strs = append(strs, quoteCharacterString(s))
+	return strings.Join(strs, " ")
+}
@@ -0,0 +1,85 @@
+package domainnameshop
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+var txtValues = map[string]string{
+	"spf":       "v=spf1 include:_spf.domeneshop.no ~all",
+	"dkim":      "v=DKIM1; k=rsa; p=" + strings.Repeat("MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA", 10),
+	"quotes":    `say "hi"`,
+	"quoted":    `"v=spf1 -all"`,
+	"strings":   `"a" "b"`,
+	"backslash": `C:\path\to\file`,
+	"special":   "semi;colon (parens) tab\tand ærlig",
+	"empty":     "",
+}
+
+func Test_TXTRoundTrip(t *testing.T) {
+	for name, text := range txtValues {
+		dsrr, err := libdnsRecordTodsDNSRecord(libdns.TXT{Name: "@", Text: text})
+		if err != nil {
+			t.Fatalf("%s: converting to API record failed => %v", name, err)
+		}
+		rec, err := dsrr.libdnsRecord()
+		if err != nil {
+			t.Fatalf("%s: converting from API record failed => %v", name, err)
+		}
+		if txt, ok := rec.(libdns.TXT); !ok || txt.Text != text {
+			t.Fatalf("%s: %q != %q", name, rec.RR().Data, text)
+		}
+
+		// Also through a zone file
+		var buf bytes.Buffer
+		if err := WriteZoneFile(&buf, "example.com", []libdns.Record{rec}); err != nil {
+			t.Fatalf("%s: WriteZoneFile failed => %v", name, err)
+		}
+		parsed, _, err := ParseZoneFile(&buf, "example.com", nil)
+		if err != nil {
+			t.Fatalf("%s: ParseZoneFile failed => %v", name, err)
+		}
+		if len(parsed) != 1 || parsed[0].RR().Data != text {
+			t.Fatalf("%s: %+v != %q", name, parsed, text)
+		}
+	}
+}
+
+func Test_TXTEncoding(t *testing.T) {
+	// Plain text is sent as it is
+	if data := encodeTXT(txtValues["dkim"]); data != txtValues["dkim"] {
+		t.Fatalf("DKIM key encoded => %q", data)
+	}
+	if data := encodeTXT(`"quoted"`); data != `"\"quoted\""` {
+		t.Fatalf("quoted text not escaped => %q", data)
+	}
+
+	// Data in zone file form, as from a pasted DKIM record
+	for data, expected := range map[string]string{
+		`"v=DKIM1; k=rsa; " "p=MIIB"`: "v=DKIM1; k=rsa; p=MIIB",
+		`"a\"b\\c\059"`:               `a"b\c;`,
+		`"unterminated`:               `"unterminated`,
+		`"a" b`:                       `"a" b`,
+		`v=spf1 -all`:                 `v=spf1 -all`,
+	} {
+		if text := decodeTXT(data); text != expected {
+			t.Fatalf("decodeTXT(%q) != %q => %q", data, expected, text)
+		}
+	}
+}
+
+func Test_quoteCharacterStrings(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	if s := quoteCharacterStrings(long); s != `"`+strings.Repeat("a", 255)+`" "`+strings.Repeat("a", 45)+`"` {
+		t.Fatalf("long text not split => %q", s)
+	}
+	if s := quoteCharacterStrings(strings.Repeat("b", 255)); strings.Count(s, `"`) != 2 {
+		t.Fatalf("255 bytes split => %q", s)
+	}
+	if s := quoteCharacterStrings(""); s != `""` {
+		t.Fatalf(`"" != %q`, s)
+	}
+}
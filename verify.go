@@ -0,0 +1,116 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// VerifyReason is the reason Verify failed.
+type VerifyReason string
+
+const (
+	// VerifyBadCredentials means the API rejected the token and secret.
+	VerifyBadCredentials VerifyReason = "bad-credentials"
+	// VerifyNoDomains means the credentials work, but the account has no domains.
+	VerifyNoDomains VerifyReason = "no-domains"
+	// VerifyNetwork means the API couldn't be reached.
+	VerifyNetwork VerifyReason = "network"
+	// VerifyAPIError means the API answered with another error.
+	VerifyAPIError VerifyReason = "api-error"
+)
+
+// VerifyError is returned by Verify.
+type VerifyError struct {
+	Reason VerifyReason
+	Err    error
+}
+
+func (e *VerifyError) Error() string {
+	switch e.Reason {
+	case VerifyBadCredentials:
+		return fmt.Sprintf("invalid Domainnameshop API credentials: %v", e.Err)
+	case VerifyNoDomains:
+		return "no domains in the Domainnameshop account"
+	case VerifyNetwork:
+		return fmt.Sprintf("can't reach the Domainnameshop API: %v", e.Err)
+	}
+	return fmt.Sprintf("verifying Domainnameshop API credentials: %v", e.Err)
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyResult describes the account of the credentials. The service lists hold the
+// names of the domains having each service.
+type VerifyResult struct {
+	Domains   []Domain `json:"domains"`
+	DNS       []string `json:"dns"`
+	Email     []string `json:"email"`
+	Registrar []string `json:"registrar"`
+	Webhotel  []string `json:"webhotel"`
+}
+
+// Verify checks the credentials by listing the domains of the account, and reports which
+// domains have the DNS, email, registrar and webhotel services. It fails with a *VerifyError
+// if the credentials are rejected, the account has no domains or the API can't be reached.
+func (p *Provider) Verify(ctx context.Context) (_ VerifyResult, err error) {
+	ctx, span := p.startSpan(ctx, "Verify")
+	defer func() { endSpan(span, err) }()
+
+	token, secret, err := p.credentials(ctx)
+	if err != nil {
+		return VerifyResult{}, &VerifyError{Reason: VerifyBadCredentials, Err: err}
+	}
+	if token == "" || secret == "" {
+		return VerifyResult{}, &VerifyError{Reason: VerifyBadCredentials, Err: errors.New("API token or secret is empty")}
+	}
+
+	domains, err := p.listDomains(ctx, token, secret, "")
+	if err != nil {
+		var apiErr *APIError
+		switch {
+		case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+			return VerifyResult{}, &VerifyError{Reason: VerifyBadCredentials, Err: err}
+		case errors.As(err, &apiErr):
+			return VerifyResult{}, &VerifyError{Reason: VerifyAPIError, Err: err}
+		default:
+			return VerifyResult{}, &VerifyError{Reason: VerifyNetwork, Err: err}
+		}
+	}
+	if len(domains) == 0 {
+		return VerifyResult{}, &VerifyError{Reason: VerifyNoDomains}
+	}
+	p.cacheDomains(domains, true)
+
+	return verifyResult(domains), nil
+}
+
+func verifyResult(domains []Domain) VerifyResult {
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Name < domains[j].Name })
+	result := VerifyResult{
+		Domains:   domains,
+		DNS:       []string{},
+		Email:     []string{},
+		Registrar: []string{},
+		Webhotel:  []string{},
+	}
+	for _, d := range domains {
+		if d.Services.DNS {
+			result.DNS = append(result.DNS, d.Name)
+		}
+		if d.Services.Email {
+			result.Email = append(result.Email, d.Name)
+		}
+		if d.Services.Registrar {
+			result.Registrar = append(result.Registrar, d.Name)
+		}
+		if d.Services.Webhotel != "" && d.Services.Webhotel != "none" {
+			result.Webhotel = append(result.Webhotel, d.Name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_Verify(t *testing.T) {
+	server := newTestAPI(t, map[string][]Domain{
+		"full": {
+			{ID: 2, Name: "example.net", Services: Service{DNS: true, Webhotel: "none"}},
+			{ID: 1, Name: "example.com", Services: Service{DNS: true, Email: true, Registrar: true, Webhotel: "small"}},
+		},
+		"empty": {},
+	}, nil)
+
+	p := &Provider{APIToken: "full", APISecret: "secret", BaseURL: server.URL}
+	result, err := p.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify failed => %v", err)
+	}
+	if len(result.Domains) != 2 || result.Domains[0].Name != "example.com" {
+		t.Fatalf("unexpected domains => %+v", result.Domains)
+	}
+	if !reflect.DeepEqual(result.DNS, []string{"example.com", "example.net"}) ||
+		!reflect.DeepEqual(result.Email, []string{"example.com"}) ||
+		!reflect.DeepEqual(result.Registrar, []string{"example.com"}) ||
+		!reflect.DeepEqual(result.Webhotel, []string{"example.com"}) {
+		t.Fatalf("unexpected services => %+v", result)
+	}
+
+	closed := newTestAPI(t, nil, nil)
+	closed.Close()
+
+	for _, test := range []struct {
+		provider *Provider
+		reason   VerifyReason
+	}{
+		{&Provider{APIToken: "wrong", APISecret: "secret", BaseURL: server.URL}, VerifyBadCredentials},
+		{&Provider{APIToken: "", APISecret: "", BaseURL: server.URL}, VerifyBadCredentials},
+		{&Provider{APIToken: "empty", APISecret: "secret", BaseURL: server.URL}, VerifyNoDomains},
+		{&Provider{APIToken: "full", APISecret: "secret", BaseURL: closed.URL}, VerifyNetwork},
+	} {
+		_, err := test.provider.Verify(context.Background())
+		var verifyErr *VerifyError
+		if !errors.As(err, &verifyErr) || verifyErr.Reason != test.reason {
+			t.Fatalf("expected %s error for token %q => %v", test.reason, test.provider.APIToken, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ChangeEvent is sent by Watch for every record change found in a zone,
+// or with Err set when polling the zone failed.
+type ChangeEvent struct {
+	Zone   string
+	Time   time.Time
+	Change RecordChange
+	Err    error
+}
+
+const (
+	// watchJitter is the fraction the poll interval is randomly varied by,
+	// so watchers started together don't poll the API at the same time.
+	watchJitter = 0.1
+	// watchMaxBackoff is the longest wait between polls after repeated errors.
+	watchMaxBackoff = 30 * time.Minute
+)
+
+// Watch polls the records of the zones about every interval and sends an event for each
+// record that was added, removed or modified since the previous poll. The first poll of a
+// zone is the baseline and produces no events.
+//
+// When polling a zone fails an event with Err is sent and the zone is polled again with
+// exponential backoff. The channel is closed after ctx is done.
+func (p *Provider) Watch(ctx context.Context, zones []string, interval time.Duration) (<-chan ChangeEvent, error) {
+	if len(zones) == 0 {
+		return nil, errors.New("no zones to watch")
+	}
+	if interval <= 0 {
+		return nil, errors.New("watch interval must be positive")
+	}
+
+	events := make(chan ChangeEvent, 16)
+	var wg sync.WaitGroup
+	for _, zone := range zones {
+		wg.Add(1)
+		go func(zone string) {
+			defer wg.Done()
+			watchZone(ctx, zone, interval, func(ctx context.Context) (_ *Snapshot, err error) {
+				ctx, span := p.startSpan(ctx, "Watch", Attribute{AttrZone, zone})
+				defer func() { endSpan(span, err) }()
+				return p.liveSnapshot(ctx, zone)
+			}, events)
+		}(zone)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+func watchZone(ctx context.Context, zone string, interval time.Duration, poll func(ctx context.Context) (*Snapshot, error), events chan<- ChangeEvent) {
+	send := func(e ChangeEvent) bool {
+		select {
+		case events <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var previous *Snapshot
+	failures := 0
+	wait := time.Duration(0)
+	for {
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		current, err := poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			failures++
+			if !send(ChangeEvent{Zone: zone, Time: time.Now(), Err: err}) {
+				return
+			}
+			wait = watchBackoff(interval, failures)
+			continue
+		}
+		failures = 0
+		wait = jitter(interval, watchJitter)
+
+		if previous != nil {
+			for _, c := range DiffSnapshots(previous, current).Changes {
+				if !send(ChangeEvent{Zone: zone, Time: current.TakenAt, Change: c}) {
+					return
+				}
+			}
+		}
+		previous = current
+	}
+}
+
+// watchBackoff doubles the interval for every consecutive failure, up to watchMaxBackoff.
+func watchBackoff(interval time.Duration, failures int) time.Duration {
+	backoff := interval
+	for i := 1; i < failures && backoff < watchMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return jitter(min(backoff, max(watchMaxBackoff, interval)), watchJitter)
+}
+
+// jitter returns d varied randomly by up to the given fraction in either direction.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	return d + time.Duration((rand.Float64()*2-1)*fraction*float64(d))
+}
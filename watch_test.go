@@ -0,0 +1,85 @@
+package domainnameshop
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_watchZone(t *testing.T) {
+	polls := []*Snapshot{
+		{Zone: "example.com", Records: []SnapshotRecord{{ID: 1, Host: "www", Type: "A", TTL: 60, Data: "192.0.2.1"}}},
+		nil, // error
+		{Zone: "example.com", Records: []SnapshotRecord{{ID: 1, Host: "www", Type: "A", TTL: 60, Data: "192.0.2.2"}}},
+		{Zone: "example.com", Records: []SnapshotRecord{{ID: 1, Host: "www", Type: "A", TTL: 60, Data: "192.0.2.2"}}},
+		{Zone: "example.com", Records: []SnapshotRecord{}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := 0
+	poll := func(ctx context.Context) (*Snapshot, error) {
+		if n >= len(polls) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		s := polls[n]
+		n++
+		if s == nil {
+			return nil, errors.New("HTTP 503")
+		}
+		return s, nil
+	}
+
+	events := make(chan ChangeEvent)
+	done := make(chan struct{})
+	go func() {
+		watchZone(ctx, "example.com", time.Millisecond, poll, events)
+		close(done)
+	}()
+
+	expected := []string{"error", string(RecordModified), string(RecordRemoved)}
+	for k, exp := range expected {
+		select {
+		case e := <-events:
+			got := string(e.Change.Kind)
+			if e.Err != nil {
+				got = "error"
+			}
+			if got != exp {
+				t.Fatalf("events[%d] != %s => %s", k, exp, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for events[%d]", k)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchZone didn't stop after cancel")
+	}
+}
+
+func Test_watchBackoff(t *testing.T) {
+	interval := time.Minute
+	for failures, exp := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 20: watchMaxBackoff} {
+		got := watchBackoff(interval, failures)
+		if got < time.Duration(float64(exp)*(1-watchJitter)) || got > time.Duration(float64(exp)*(1+watchJitter)) {
+			t.Fatalf("watchBackoff(%s, %d) not around %s => %s", interval, failures, exp, got)
+		}
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	p := &Provider{}
+	if _, err := p.Watch(context.Background(), nil, time.Minute); err == nil {
+		t.Fatal("expected error without zones")
+	}
+	if _, err := p.Watch(context.Background(), []string{"example.com"}, 0); err == nil {
+		t.Fatal("expected error without interval")
+	}
+}
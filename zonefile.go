@@ -0,0 +1,167 @@
+package domainnameshop
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ExportZone writes all records of the zone to w as an RFC 1035 master file,
+// the format used by BIND. Records are sorted so exports of the same zone can be diffed.
+func (p *Provider) ExportZone(ctx context.Context, zone string, w io.Writer) (err error) {
+	ctx, span := p.startSpan(ctx, "ExportZone", Attribute{AttrZone, zone})
+	defer func() { endSpan(span, err) }()
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	return WriteZoneFile(w, zone, records)
+}
+
+// WriteZoneFile writes records, with names relative to zone, to w as an RFC 1035 master file.
+func WriteZoneFile(w io.Writer, zone string, records []libdns.Record) error {
+	origin := removeFQDNTrailingDot(zone) + "."
+
+	sorted := make([]libdns.Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].RR(), sorted[j].RR()
+		an, bn := zoneFileName(a.Name, origin), zoneFileName(b.Name, origin)
+		if an != bn {
+			if an == "@" || bn == "@" {
+				return an == "@"
+			}
+			return an < bn
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Data < b.Data
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "$ORIGIN %s\n", origin)
+	fmt.Fprintf(bw, "$TTL %d\n", int(commonTTL(sorted).Seconds()))
+	for _, rec := range sorted {
+		rr := rec.RR()
+		data, err := presentationData(rec, origin)
+		if err != nil {
+			return fmt.Errorf("rendering %s %s record: %v", rr.Name, rr.Type, err)
+		}
+		fmt.Fprintf(bw, "%s\t%d\tIN\t%s\t%s\n", zoneFileName(rr.Name, origin), int(rr.TTL.Seconds()), rr.Type, data)
+	}
+
+	return bw.Flush()
+}
+
+// zoneFileName returns name relative to origin, or absolute if it's outside of it.
+func zoneFileName(name string, origin string) string {
+	if name == "" || name == "@" {
+		return "@"
+	}
+	if !strings.HasSuffix(name, ".") {
+		return name
+	}
+	if name == origin {
+		return "@"
+	}
+	if strings.HasSuffix(name, "."+origin) {
+		return strings.TrimSuffix(name, "."+origin)
+	}
+	return name
+}
+
+// absoluteTarget makes a target hostname absolute, the API stores them without trailing dot.
+func absoluteTarget(target string, origin string) string {
+	if target == "@" {
+		return origin
+	}
+	if strings.HasSuffix(target, ".") {
+		return target
+	}
+	return target + "."
+}
+
+// presentationData renders the data of rec in master file presentation format.
+func presentationData(rec libdns.Record, origin string) (string, error) {
+	if generic, ok := rec.(libdns.RR); ok {
+		parsed, err := generic.Parse()
+		if err != nil {
+			return "", err
+		}
+		rec = parsed
+	}
+
+	switch r := rec.(type) {
+	case libdns.TXT:
+		return quoteCharacterStrings(r.Text), nil
+	case libdns.CNAME:
+		return absoluteTarget(r.Target, origin), nil
+	case libdns.NS:
+		return absoluteTarget(r.Target, origin), nil
+	case libdns.MX:
+		return fmt.Sprintf("%d %s", r.Preference, absoluteTarget(r.Target, origin)), nil
+	case libdns.SRV:
+		return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, absoluteTarget(r.Target, origin)), nil
+	case libdns.CAA:
+		return fmt.Sprintf("%d %s %s", r.Flags, r.Tag, quoteCharacterString(r.Value)), nil
+	case libdns.RR:
+		switch r.Type {
+		case "ANAME":
+			return absoluteTarget(r.Data, origin), nil
+		case "TLSA", "DS":
+			// Already "usage selector matching-type data" and "key-tag algorithm digest-type digest"
+			fields := strings.Fields(r.Data)
+			if len(fields) != 4 {
+				return "", fmt.Errorf("malformed %s value %q", r.Type, r.Data)
+			}
+			fields[3] = strings.ToUpper(fields[3])
+			return strings.Join(fields, " "), nil
+		}
+		return r.Data, nil
+	default:
+		return rec.RR().Data, nil
+	}
+}
+
+// quoteCharacterString quotes s as a <character-string>, escaping quotes,
+// backslashes and non-printable bytes as described in RFC 1035 section 5.1.
+func quoteCharacterString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			b.WriteString(fmt.Sprintf("\\%03d", c))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// commonTTL returns the most used TTL, used as the $TTL of the file.
+func commonTTL(records []libdns.Record) time.Duration {
+	counts := make(map[time.Duration]int)
+	best := defaultTtl
+	for _, rec := range records {
+		rr := rec.RR()
+		counts[rr.TTL]++
+		if counts[rr.TTL] > counts[best] || (counts[rr.TTL] == counts[best] && rr.TTL < best) {
+			best = rr.TTL
+		}
+	}
+	return best
+}
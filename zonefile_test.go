@@ -0,0 +1,102 @@
+package domainnameshop
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_WriteZoneFile(t *testing.T) {
+	records := []libdns.Record{
+		libdns.TXT{Name: "txt", TTL: time.Hour, Text: `v=spf1 "quoted" \ back` + "\t"},
+		libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.com"},
+		libdns.Address{Name: "www", TTL: 2 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.CNAME{Name: "alias", TTL: 2 * time.Minute, Target: "www.example.com."},
+		libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: 2 * time.Minute, Priority: 10, Weight: 5, Port: 5060, Target: "sip.example.net"},
+		libdns.CAA{Name: "@", TTL: 2 * time.Minute, Flags: 0, Tag: "issue", Value: "letsencrypt.org"},
+		libdns.RR{Name: "_443._tcp.www", TTL: 2 * time.Minute, Type: "TLSA", Data: "3 1 1 abcdef0123"},
+		libdns.RR{Name: "sub", TTL: 2 * time.Minute, Type: "DS", Data: "12345 13 2 abcdef"},
+		libdns.RR{Name: "@", TTL: 2 * time.Minute, Type: "ANAME", Data: "target.example.net"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZoneFile(&buf, "example.com.", records); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "$ORIGIN example.com.\n" +
+		"$TTL 120\n" +
+		"@\t120\tIN\tANAME\ttarget.example.net.\n" +
+		"@\t120\tIN\tCAA\t0 issue \"letsencrypt.org\"\n" +
+		"@\t3600\tIN\tMX\t10 mail.example.com.\n" +
+		"_443._tcp.www\t120\tIN\tTLSA\t3 1 1 ABCDEF0123\n" +
+		"_sip._tcp\t120\tIN\tSRV\t10 5 5060 sip.example.net.\n" +
+		"alias\t120\tIN\tCNAME\twww.example.com.\n" +
+		"sub\t120\tIN\tDS\t12345 13 2 ABCDEF\n" +
+		"txt\t3600\tIN\tTXT\t\"v=spf1 \\\"quoted\\\" \\\\ back\\009\"\n" +
+		"www\t120\tIN\tA\t192.0.2.1\n"
+	if buf.String() != expected {
+		t.Fatalf("zone file mismatch\ngot:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func Test_ZoneFileRoundTrip(t *testing.T) {
+	records := []libdns.Record{
+		libdns.RR{Name: "@", TTL: time.Hour, Type: "ANAME", Data: "target.example.net"},
+		libdns.CNAME{Name: "alias", TTL: time.Hour, Target: "www.example.com."},
+		libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.net"},
+		libdns.NS{Name: "sub", TTL: time.Hour, Target: "ns1.example.net"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZoneFile(&buf, "example.com", records); err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := ParseZoneFile(&buf, "example.com", nil)
+	if err != nil {
+		t.Fatalf("ParseZoneFile failed => %v", err)
+	}
+	if len(parsed) != len(records) {
+		t.Fatalf("len(parsed) != %d => %+v", len(records), parsed)
+	}
+
+	// Targets outside the zone aren't taken to be relative to it
+	expected := map[string]string{
+		"ANAME": "target.example.net",
+		"CNAME": "www.example.com",
+		"MX":    "10 mail.example.net",
+		"NS":    "ns1.example.net",
+	}
+	for _, rec := range parsed {
+		rr := rec.RR()
+		if rr.Data != expected[rr.Type] {
+			t.Fatalf("%s data %q != %q", rr.Type, rr.Data, expected[rr.Type])
+		}
+	}
+}
+
+func Test_dsDNSRecordTypeFields(t *testing.T) {
+	testCases := []libdns.Record{
+		libdns.CAA{Name: "@", TTL: time.Hour, Flags: 128, Tag: "issuewild", Value: ";"},
+		libdns.RR{Name: "_25._tcp.mail", TTL: time.Hour, Type: "TLSA", Data: "3 1 1 abcdef"},
+		libdns.RR{Name: "sub", TTL: time.Hour, Type: "DS", Data: "12345 13 2 abcdef"},
+		libdns.MX{Name: "@", TTL: time.Hour, Preference: 10, Target: "mail.example.com"},
+	}
+
+	for _, rec := range testCases {
+		ds, err := libdnsRecordTodsDNSRecord(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := ds.libdnsRecord()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back.RR() != rec.RR() {
+			t.Fatalf("round trip mismatch => %+v != %+v", back.RR(), rec.RR())
+		}
+	}
+}
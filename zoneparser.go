@@ -0,0 +1,455 @@
+package domainnameshop
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// supportedRecordTypes are the record types the Domeneshop API can manage.
+var supportedRecordTypes = map[string]bool{
+	"A": true, "AAAA": true, "ANAME": true, "CAA": true, "CNAME": true, "DS": true,
+	"MX": true, "NS": true, "SRV": true, "TLSA": true, "TXT": true,
+}
+
+// ZoneFileOptions configures ParseZoneFile.
+type ZoneFileOptions struct {
+	// Include is used to open files named by $INCLUDE directives.
+	// If nil, $INCLUDE is an error.
+	Include fs.FS
+}
+
+// ZoneFileWarning is a record that was skipped while parsing a zone file.
+type ZoneFileWarning struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (w ZoneFileWarning) String() string {
+	if w.File != "" {
+		return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Message)
+	}
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// ParseZoneFile parses an RFC 1035 master file (as used by BIND) for zone and
+// returns its records with names relative to zone.
+//
+// SOA records, NS records at the zone apex, records outside of the zone and
+// record types the Domeneshop API doesn't support are skipped with a warning.
+func ParseZoneFile(r io.Reader, zone string, opts *ZoneFileOptions) ([]libdns.Record, []ZoneFileWarning, error) {
+	if opts == nil {
+		opts = &ZoneFileOptions{}
+	}
+	zp := &zoneParser{
+		zone: strings.ToLower(removeFQDNTrailingDot(zone)) + ".",
+		opts: opts,
+	}
+	if err := zp.parse(r, "", zp.zone, 0); err != nil {
+		return nil, nil, err
+	}
+	return zp.records, zp.warnings, nil
+}
+
+// maxIncludeDepth guards against $INCLUDE loops.
+const maxIncludeDepth = 8
+
+type zoneParser struct {
+	zone string
+	opts *ZoneFileOptions
+
+	defaultTTL time.Duration // From $TTL
+	lastTTL    time.Duration
+	lastOwner  string
+
+	records  []libdns.Record
+	warnings []ZoneFileWarning
+}
+
+func (zp *zoneParser) parse(r io.Reader, file string, origin string, depth int) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	entries, err := lexZoneFile(string(data))
+	if err != nil {
+		return zoneFileError(file, err)
+	}
+
+	for _, e := range entries {
+		fail := func(format string, args ...any) error {
+			return zoneFileError(file, fmt.Errorf("line %d: %s", e.line, fmt.Sprintf(format, args...)))
+		}
+
+		if !e.blankOwner && strings.HasPrefix(e.tokens[0], "$") {
+			switch strings.ToUpper(e.tokens[0]) {
+			case "$ORIGIN":
+				if len(e.tokens) != 2 {
+					return fail("$ORIGIN expects one domain name")
+				}
+				origin = absoluteZoneName(e.tokens[1], origin)
+
+			case "$TTL":
+				if len(e.tokens) != 2 {
+					return fail("$TTL expects one TTL")
+				}
+				ttl, err := parseZoneTTL(e.tokens[1])
+				if err != nil {
+					return fail("%v", err)
+				}
+				zp.defaultTTL = ttl
+
+			case "$INCLUDE":
+				if len(e.tokens) < 2 || len(e.tokens) > 3 {
+					return fail("$INCLUDE expects a file name and an optional origin")
+				}
+				if zp.opts.Include == nil {
+					return fail("$INCLUDE %s is not allowed", e.tokens[1])
+				}
+				if depth >= maxIncludeDepth {
+					return fail("$INCLUDE nested too deep")
+				}
+				includeOrigin := origin
+				if len(e.tokens) == 3 {
+					includeOrigin = absoluteZoneName(e.tokens[2], origin)
+				}
+				f, err := zp.opts.Include.Open(e.tokens[1])
+				if err != nil {
+					return fail("%v", err)
+				}
+				err = zp.parse(f, e.tokens[1], includeOrigin, depth+1)
+				f.Close()
+				if err != nil {
+					return err
+				}
+
+			default:
+				return fail("unknown directive %s", e.tokens[0])
+			}
+			continue
+		}
+
+		tokens := e.tokens
+		owner := zp.lastOwner
+		if !e.blankOwner {
+			owner = absoluteZoneName(tokens[0], origin)
+			tokens = tokens[1:]
+		}
+		if owner == "" {
+			return fail("record without owner name")
+		}
+		zp.lastOwner = owner
+
+		// TTL and class are both optional and may come in any order
+		ttl := time.Duration(-1)
+		for i := 0; i < 2 && len(tokens) > 0; i++ {
+			if isZoneClass(tokens[0]) {
+				if !strings.EqualFold(tokens[0], "IN") {
+					return fail("unsupported class %s", tokens[0])
+				}
+				tokens = tokens[1:]
+			} else if t, err := parseZoneTTL(tokens[0]); err == nil && ttl < 0 {
+				ttl = t
+				tokens = tokens[1:]
+			}
+		}
+		if len(tokens) == 0 {
+			return fail("missing record type")
+		}
+		switch {
+		case ttl >= 0:
+		case zp.defaultTTL > 0:
+			ttl = zp.defaultTTL
+		case zp.lastTTL > 0:
+			ttl = zp.lastTTL
+		default:
+			ttl = defaultTtl
+		}
+		zp.lastTTL = ttl
+
+		rrType := strings.ToUpper(tokens[0])
+		warn := func(format string, args ...any) {
+			zp.warnings = append(zp.warnings, ZoneFileWarning{
+				File:    file,
+				Line:    e.line,
+				Message: fmt.Sprintf("skipping %s %s: %s", owner, rrType, fmt.Sprintf(format, args...)),
+			})
+		}
+
+		if owner != zp.zone && !strings.HasSuffix(owner, "."+zp.zone) {
+			warn("outside of zone %s", zp.zone)
+			continue
+		}
+		switch {
+		case rrType == "SOA":
+			warn("SOA is managed by Domeneshop")
+			continue
+		case rrType == "NS" && owner == zp.zone:
+			warn("apex NS records are managed by Domeneshop")
+			continue
+		case !supportedRecordTypes[rrType]:
+			warn("record type is not supported by Domeneshop")
+			continue
+		}
+
+		rec, err := zoneFileRecord(libdns.RelativeName(owner, zp.zone), ttl, rrType, tokens[1:], origin)
+		if err != nil {
+			return fail("%s %s: %v", owner, rrType, err)
+		}
+		zp.records = append(zp.records, rec)
+	}
+
+	return nil
+}
+
+// zoneFileRecord converts the presentation format rdata of a record to a libdns record.
+func zoneFileRecord(name string, ttl time.Duration, rrType string, rdata []string, origin string) (libdns.Record, error) {
+	want := func(n int) error {
+		if len(rdata) != n {
+			return fmt.Errorf("expected %d fields, got %d", n, len(rdata))
+		}
+		return nil
+	}
+	target := func(s string) string {
+		return removeFQDNTrailingDot(absoluteZoneName(s, origin))
+	}
+
+	rr := libdns.RR{Name: name, TTL: ttl, Type: rrType}
+	switch rrType {
+	case "TXT":
+		if len(rdata) == 0 {
+			return nil, fmt.Errorf("missing text")
+		}
+		// libdns treats the character-strings of a TXT record as one string
+		return libdns.TXT{Name: name, TTL: ttl, Text: strings.Join(rdata, "")}, nil
+
+	case "CAA":
+		if err := want(3); err != nil {
+			return nil, err
+		}
+		flags, err := strconv.ParseUint(rdata[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags %s: %v", rdata[0], err)
+		}
+		return libdns.CAA{Name: name, TTL: ttl, Flags: uint8(flags), Tag: rdata[1], Value: rdata[2]}, nil
+
+	case "CNAME", "NS", "ANAME":
+		if err := want(1); err != nil {
+			return nil, err
+		}
+		rr.Data = target(rdata[0])
+
+	case "MX":
+		if err := want(2); err != nil {
+			return nil, err
+		}
+		rr.Data = rdata[0] + " " + target(rdata[1])
+
+	case "SRV":
+		if err := want(4); err != nil {
+			return nil, err
+		}
+		rr.Data = strings.Join(rdata[:3], " ") + " " + target(rdata[3])
+
+	case "TLSA", "DS":
+		// The hex data may be split in several fields
+		if len(rdata) < 4 {
+			return nil, fmt.Errorf("expected at least 4 fields, got %d", len(rdata))
+		}
+		rr.Data = strings.Join(rdata[:3], " ") + " " + strings.Join(rdata[3:], "")
+
+	default:
+		if err := want(1); err != nil {
+			return nil, err
+		}
+		rr.Data = rdata[0]
+	}
+
+	return rr.Parse()
+}
+
+// absoluteZoneName makes name absolute relative to origin, "@" is the origin itself.
+func absoluteZoneName(name string, origin string) string {
+	if name == "@" {
+		return origin
+	}
+	if strings.HasSuffix(name, ".") {
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name) + "." + origin
+}
+
+func isZoneClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "HS", "CS":
+		return true
+	}
+	return strings.HasPrefix(strings.ToUpper(s), "CLASS")
+}
+
+// parseZoneTTL parses a TTL in seconds, or with BIND style units like 1h30m.
+func parseZoneTTL(s string) (time.Duration, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	if n, err := strconv.ParseUint(s, 10, 31); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	var ttl time.Duration
+	var n uint64
+	digits := false
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			n = n*10 + uint64(c-'0')
+			digits = true
+			continue
+		}
+		unit, ok := map[rune]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[c]
+		if !ok || !digits {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		ttl += time.Duration(n) * unit
+		n, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	return ttl, nil
+}
+
+// zoneEntry is a logical line of a master file, which may span several
+// physical lines inside parentheses.
+type zoneEntry struct {
+	line       int
+	blankOwner bool // The entry starts with whitespace and uses the previous owner
+	tokens     []string
+}
+
+// lexZoneFile splits a master file into entries, removing comments and
+// resolving quotes and escapes as described in RFC 1035 section 5.1.
+func lexZoneFile(s string) ([]zoneEntry, error) {
+	var entries []zoneEntry
+	line := 1
+	parens := 0
+	var current *zoneEntry
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		if current == nil {
+			current = &zoneEntry{line: line, blankOwner: c == ' ' || c == '\t'}
+		}
+
+		switch {
+		case c == '\n':
+			line++
+			i++
+			if parens == 0 {
+				if len(current.tokens) > 0 {
+					entries = append(entries, *current)
+				}
+				current = nil
+			}
+
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+
+		case c == ';':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+
+		case c == '(':
+			parens++
+			i++
+
+		case c == ')':
+			if parens == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced parenthesis", line)
+			}
+			parens--
+			i++
+
+		case c == '"':
+			i++
+			var b strings.Builder
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("line %d: unterminated quoted string", current.line)
+				}
+				if s[i] == '"' {
+					i++
+					break
+				}
+				if s[i] == '\n' {
+					line++
+				}
+				n, err := unescapeZoneChar(s[i:], &b)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+				i += n
+			}
+			current.tokens = append(current.tokens, b.String())
+
+		default:
+			var b strings.Builder
+			for i < len(s) && !strings.ContainsRune(" \t\r\n;()\"", rune(s[i])) {
+				n, err := unescapeZoneChar(s[i:], &b)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+				i += n
+			}
+			current.tokens = append(current.tokens, b.String())
+		}
+	}
+
+	if parens > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parenthesis", line)
+	}
+	if current != nil && len(current.tokens) > 0 {
+		entries = append(entries, *current)
+	}
+	return entries, nil
+}
+
+// unescapeZoneChar writes the first, possibly escaped, character of s to b and
+// returns how many bytes of s it used. \DDD is a decimal byte value and \X is X.
+func unescapeZoneChar(s string, b *strings.Builder) (int, error) {
+	if s[0] != '\\' {
+		b.WriteByte(s[0])
+		return 1, nil
+	}
+	if len(s) < 2 {
+		return 0, fmt.Errorf("dangling escape")
+	}
+	if s[1] >= '0' && s[1] <= '9' {
+		if len(s) < 4 {
+			return 0, fmt.Errorf("invalid escape %s", s)
+		}
+		v, err := strconv.ParseUint(s[1:4], 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid escape \\%s", s[1:4])
+		}
+		b.WriteByte(byte(v))
+		return 4, nil
+	}
+	b.WriteByte(s[1])
+	return 2, nil
+}
+
+func zoneFileError(file string, err error) error {
+	if file == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %v", file, err)
+}
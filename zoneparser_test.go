@@ -0,0 +1,128 @@
+package domainnameshop
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_ParseZoneFile(t *testing.T) {
+	zoneFile := `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.hyp.net. hostmaster.example.com. (
+		2024010101 ; serial
+		3h 1h 1w 1h )
+	IN	NS	ns1.hyp.net.
+	IN	MX	10 mail
+www	300	IN	A	192.0.2.1
+	IN	AAAA	2001:db8::1 ; same owner
+txt	IN	TXT	"v=spf1 \"quoted\" \\ back" "second\059part"
+long	TXT	( "part one "
+		"part two" )
+_sip._tcp	SRV	10 5 5060 sip.example.net.
+@	CAA	0 issue "letsencrypt.org"
+_443._tcp.www	TLSA	3 1 1 ( abcdef
+		012345 )
+sub	NS	ns.other.net.
+other.org.	A	192.0.2.2
+weird	HINFO	"cpu" "os"
+$ORIGIN sub2.example.com.
+alias	1d	CNAME	www.example.com.
+$INCLUDE extra.zone
+`
+	include := fstest.MapFS{
+		"extra.zone": {Data: []byte("included A 192.0.2.3\n")},
+	}
+
+	records, warnings, err := ParseZoneFile(strings.NewReader(zoneFile), "example.com", &ZoneFileOptions{Include: include})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []libdns.RR{
+		{Name: "@", TTL: time.Hour, Type: "MX", Data: "10 mail.example.com"},
+		{Name: "www", TTL: 300 * time.Second, Type: "A", Data: "192.0.2.1"},
+		{Name: "www", TTL: time.Hour, Type: "AAAA", Data: "2001:db8::1"},
+		{Name: "txt", TTL: time.Hour, Type: "TXT", Data: `v=spf1 "quoted" \ back` + "second;part"},
+		{Name: "long", TTL: time.Hour, Type: "TXT", Data: "part one part two"},
+		{Name: "_sip._tcp", TTL: time.Hour, Type: "SRV", Data: "10 5 5060 sip.example.net"},
+		{Name: "@", TTL: time.Hour, Type: "CAA", Data: `0 issue "letsencrypt.org"`},
+		{Name: "_443._tcp.www", TTL: time.Hour, Type: "TLSA", Data: "3 1 1 abcdef012345"},
+		{Name: "sub", TTL: time.Hour, Type: "NS", Data: "ns.other.net"},
+		{Name: "alias.sub2", TTL: 24 * time.Hour, Type: "CNAME", Data: "www.example.com"},
+		{Name: "included.sub2", TTL: time.Hour, Type: "A", Data: "192.0.2.3"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("len(records) != len(expected) => %d != %d: %v", len(records), len(expected), records)
+	}
+	for k, rec := range records {
+		if rr := rec.RR(); rr != expected[k] {
+			t.Fatalf("records[%d] != expected[%d] => %+v != %+v", k, k, rr, expected[k])
+		}
+	}
+
+	expectedWarnings := []string{"SOA", "apex NS", "outside of zone", "not supported"}
+	if len(warnings) != len(expectedWarnings) {
+		t.Fatalf("len(warnings) != %d => %v", len(expectedWarnings), warnings)
+	}
+	for k, w := range warnings {
+		if !strings.Contains(w.String(), expectedWarnings[k]) {
+			t.Fatalf("warnings[%d] doesn't mention %q => %s", k, expectedWarnings[k], w)
+		}
+	}
+}
+
+func Test_ParseZoneFileErrors(t *testing.T) {
+	testCases := map[string]string{
+		"unbalanced parens":   "www A ( 192.0.2.1\n",
+		"unterminated quote":  "txt TXT \"abc\n",
+		"include not allowed": "$INCLUDE other.zone\n",
+		"unknown directive":   "$GENERATE 1-2 host$ A 192.0.2.1\n",
+		"missing owner":       "  A 192.0.2.1\n",
+		"bad MX":              "@ MX mail.example.com.\n",
+		"other class":         "www CH A 192.0.2.1\n",
+	}
+
+	for name, zoneFile := range testCases {
+		if _, _, err := ParseZoneFile(strings.NewReader(zoneFile), "example.com.", nil); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func Test_planChanges(t *testing.T) {
+	live := []dsDNSRecord{
+		{ID: 1, Host: "www", Type: "A", Data: "192.0.2.1", TTL: 3600},
+		{ID: 2, Host: "mail", Type: "A", Data: "192.0.2.2", TTL: 3600},
+		{ID: 3, Host: "old", Type: "TXT", Data: "old", TTL: 3600},
+		{ID: 4, Host: "@", Type: "MX", Data: "mail.example.com", Priority: "10", TTL: 3600},
+	}
+	desired := []dsDNSRecord{
+		{Host: "www", Type: "A", Data: "192.0.2.1", TTL: 300},
+		{Host: "mail", Type: "A", Data: "192.0.2.20", TTL: 3600},
+		{Host: "@", Type: "MX", Data: "mail.example.com.", Priority: "10", TTL: 3600},
+		{Host: "new", Type: "TXT", Data: "new", TTL: 3600},
+	}
+
+	plan := planChanges(live, desired, false)
+	if len(plan.update) != 1 || plan.update[0].after.ID != 1 || plan.update[0].after.TTL != 300 {
+		t.Fatalf("expected TTL update of record 1 => %+v", plan.update)
+	}
+	if len(plan.create) != 2 || len(plan.delete) != 0 {
+		t.Fatalf("expected 2 creates and no deletes => %+v", plan)
+	}
+
+	plan = planChanges(live, desired, true)
+	if len(plan.update) != 2 || plan.update[1].before.ID != 2 || plan.update[1].after.Data != "192.0.2.20" {
+		t.Fatalf("expected record 2 updated in place => %+v", plan.update)
+	}
+	if len(plan.create) != 1 || plan.create[0].Host != "new" {
+		t.Fatalf("expected create of new => %+v", plan.create)
+	}
+	if len(plan.delete) != 1 || plan.delete[0].ID != 3 {
+		t.Fatalf("expected delete of record 3 => %+v", plan.delete)
+	}
+}